@@ -0,0 +1,246 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Financial-Times/go-logger/v2"
+	"github.com/Financial-Times/public-content-by-concept-api/v2/content"
+	"github.com/Financial-Times/public-content-by-concept-api/v2/problem"
+	transactionidutils "github.com/Financial-Times/transactionid-utils-go"
+)
+
+const maxBatchBodySize = 1 << 20
+
+// batchParams are the query parameters accepted by the batch endpoint. The publication filter of the policy
+// is applied to every concept in the batch, which can only narrow it down.
+var batchParams = []string{"publication", "allowEmpty", "includeConcept", "strict"}
+
+// batchRequest asks for the content of many concepts at once.
+type batchRequest struct {
+	Concepts []batchConcept `json:"concepts"`
+}
+
+// batchConcept asks for the content of one of the concepts in a batch, with the parameters of /content.
+type batchConcept struct {
+	IsAnnotatedBy      string   `json:"isAnnotatedBy"`
+	Page               int      `json:"page,omitempty"`
+	Limit              int      `json:"limit,omitempty"`
+	FromDate           string   `json:"fromDate,omitempty"`
+	ToDate             string   `json:"toDate,omitempty"`
+	Publication        []string `json:"publication,omitempty"`
+	Sort               string   `json:"sort,omitempty"`
+	MaxPerGroup        int      `json:"maxPerGroup,omitempty"`
+	GroupBy            string   `json:"groupBy,omitempty"`
+	IncludeAnnotations bool     `json:"includeAnnotations,omitempty"`
+}
+
+// batchContent is the content found for one of the concepts in a batch.
+type batchContent struct {
+	Concept *content.Concept  `json:"concept,omitempty"`
+	Content []content.Content `json:"content"`
+}
+
+// batchError is why no content is returned for one of the concepts in a batch.
+type batchError struct {
+	Error problem.Details `json:"error"`
+}
+
+type batchResponse struct {
+	Results map[string]interface{} `json:"results"`
+}
+
+// GetContentByConcepts returns the content for each of the concepts in the request body, keyed by their isAnnotatedBy.
+// Errors for one of the concepts are returned in its place rather than failing the whole batch.
+func (h *Handler) GetContentByConcepts(w http.ResponseWriter, r *http.Request) {
+	transID := transactionidutils.GetTransactionIDFromRequest(r)
+	ctx := transactionidutils.TransactionAwareContext(r.Context(), transID)
+
+	logEntry := h.Log.WithTransactionID(transID)
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set(transactionidutils.TransactionIDHeader, transID)
+
+	query := r.URL.Query()
+	strict, err := h.strictRequested(query, batchParams)
+	if err != nil {
+		writeRequestError(ctx, w, err)
+		return
+	}
+
+	opts, err := parseResponseOptions(query)
+	if err != nil {
+		writeRequestError(ctx, w, err)
+		return
+	}
+
+	var req batchRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBodySize))
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	if err = dec.Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeProblem(ctx, w, http.StatusRequestEntityTooLarge, "", fmt.Sprintf("Request body exceeds the maximum of %d bytes", tooLarge.Limit))
+			return
+		}
+		writeProblem(ctx, w, http.StatusBadRequest, "", fmt.Sprintf("Could not parse the request body: %s", err))
+		return
+	}
+
+	if len(req.Concepts) == 0 {
+		writeProblem(ctx, w, http.StatusBadRequest, "concepts", "Missing or empty concepts. Expecting at least one concept.")
+		return
+	}
+	if h.MaxBatchSize > 0 && len(req.Concepts) > h.MaxBatchSize {
+		writeProblem(ctx, w, http.StatusBadRequest, "concepts", fmt.Sprintf("Batch of %d concepts exceeds the maximum of %d", len(req.Concepts), h.MaxBatchSize))
+		return
+	}
+
+	results := make(map[string]interface{}, len(req.Concepts))
+	queries := make([]content.ConceptQuery, 0, len(req.Concepts))
+	keys := make([]string, 0, len(req.Concepts))
+	for _, concept := range req.Concepts {
+		key := concept.IsAnnotatedBy
+		if _, duplicate := results[key]; duplicate || slices.Contains(keys, key) {
+			writeProblem(ctx, w, http.StatusBadRequest, "concepts", fmt.Sprintf("Concept %s is asked for more than once", key))
+			return
+		}
+
+		conceptQuery, err := h.batchQuery(concept, query["publication"], strict, logEntry)
+		if err != nil {
+			results[key] = batchError{Error: requestProblem(err)}
+			continue
+		}
+		queries = append(queries, conceptQuery)
+		keys = append(keys, key)
+	}
+
+	dbStart := time.Now()
+	for i, result := range h.ContentService.GetContentForConcepts(ctx, queries) {
+		conceptUUID := queries[i].ConceptUUID
+		if p := contentProblem(result.Err, opts, conceptUUID, logEntry.WithUUID(conceptUUID)); p != nil {
+			results[keys[i]] = batchError{Error: *p}
+			continue
+		}
+
+		found := batchContent{Content: result.Content}
+		if found.Content == nil {
+			found.Content = []content.Content{}
+		}
+		if opts.includeConcept {
+			found.Concept = result.Concept
+		}
+		results[keys[i]] = found
+	}
+	recordTiming(ctx, timingDB, dbStart)
+
+	encoded, err := encodeJSON(ctx, batchResponse{Results: results})
+	if err != nil {
+		msg := "Error parsing returned content lists for batch"
+		logEntry.WithError(err).Error(msg)
+		writeProblem(ctx, w, http.StatusInternalServerError, "", msg)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeServerTiming(ctx, w)
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(encoded); err != nil {
+		logEntry.WithError(err).Error("Error writing content lists for batch")
+	}
+}
+
+// batchQuery validates the concept and its parameters the same way /content does.
+// Concepts can only be referred to by UUID or URI as resolving authority identifiers would take a query each.
+func (h *Handler) batchQuery(concept batchConcept, policyPublications []string, strict bool, logEntry *logger.LogEntry) (content.ConceptQuery, error) {
+	if concept.IsAnnotatedBy == "" {
+		return content.ConceptQuery{}, newParamError("isAnnotatedBy", "Missing or empty isAnnotatedBy. Expecting valid absolute concept URI.")
+	}
+
+	ref, err := parseConceptRef(concept.IsAnnotatedBy)
+	if err != nil {
+		return content.ConceptQuery{}, err
+	}
+	if ref.uuid == "" {
+		return content.ConceptQuery{}, newParamError("isAnnotatedBy", "Authority identifiers are not supported in batches. Expecting a concept UUID or URI.")
+	}
+
+	publication, err := batchPublications(concept.Publication, policyPublications)
+	if err != nil {
+		return content.ConceptQuery{}, err
+	}
+
+	params, err := h.extractRequestParams(concept.values(publication), strict, logEntry.WithUUID(ref.uuid))
+	if err != nil {
+		return content.ConceptQuery{}, err
+	}
+	return content.ConceptQuery{ConceptUUID: ref.uuid, Params: params}, nil
+}
+
+// batchPublications returns the publications the content of a concept in a batch is filtered by. The policy only
+// checks the query string, so the publications in the body have to be among the ones in the query string, if any.
+func batchPublications(requested, allowed []string) ([]string, error) {
+	allowed = splitPublications(allowed)
+	requested = splitPublications(requested)
+	if len(requested) == 0 || len(allowed) == 0 {
+		return slices.Concat(requested, allowed), nil
+	}
+	for _, pub := range requested {
+		if !slices.Contains(allowed, pub) {
+			return nil, fmt.Errorf("%w: %s", errPublicationForbidden, pub)
+		}
+	}
+	return requested, nil
+}
+
+// splitPublications returns the publications given either repeated or as comma separated lists.
+func splitPublications(publications []string) []string {
+	var split []string
+	for _, pub := range publications {
+		split = append(split, strings.Split(pub, ",")...)
+	}
+	return split
+}
+
+// values returns the parameters of the concept as if they were the query parameters of /content,
+// filtered by the publications.
+func (c batchConcept) values(publication []string) url.Values {
+	val := url.Values{}
+	if c.Page != 0 {
+		val.Set("page", strconv.Itoa(c.Page))
+	}
+	if c.Limit != 0 {
+		val.Set("limit", strconv.Itoa(c.Limit))
+	}
+	if c.FromDate != "" {
+		val.Set("fromDate", c.FromDate)
+	}
+	if c.ToDate != "" {
+		val.Set("toDate", c.ToDate)
+	}
+	if len(publication) > 0 {
+		val["publication"] = publication
+	}
+	if c.Sort != "" {
+		val.Set("sort", c.Sort)
+	}
+	if c.MaxPerGroup != 0 {
+		val.Set("maxPerGroup", strconv.Itoa(c.MaxPerGroup))
+	}
+	if c.GroupBy != "" {
+		val.Set("groupBy", c.GroupBy)
+	}
+	if c.IncludeAnnotations {
+		val.Set("includeAnnotations", "true")
+	}
+	return val
+}
@@ -0,0 +1,42 @@
+package content
+
+import (
+	"slices"
+)
+
+// relationshipsAggregate collects the types of the relationships rel between the content and the concepts.
+const relationshipsAggregate = "collect(DISTINCT type(rel)) as relationships"
+
+// annotationPredicates returns the annotation predicates of the relationships between content and concepts, sorted.
+// Relationships that are not annotations are left out.
+func annotationPredicates(relationships []string) []string {
+	predicates := make([]string, 0, len(relationships))
+	for predicate, relationship := range predicateRelationships {
+		if slices.Contains(relationships, relationship) {
+			predicates = append(predicates, predicate)
+		}
+	}
+	slices.Sort(predicates)
+	return predicates
+}
+
+// mergeRelationships merges the rows of the same content, which the implicit query returns once for every path
+// the content was found through, keeping the first row of each content in place.
+func mergeRelationships(results []contentResult) []contentResult {
+	merged := make([]contentResult, 0, len(results))
+	index := make(map[string]int, len(results))
+	for _, result := range results {
+		i, found := index[result.UUID]
+		if !found {
+			index[result.UUID] = len(merged)
+			merged = append(merged, result)
+			continue
+		}
+		for _, relationship := range result.Relationships {
+			if !slices.Contains(merged[i].Relationships, relationship) {
+				merged[i].Relationships = append(merged[i].Relationships, relationship)
+			}
+		}
+	}
+	return merged
+}
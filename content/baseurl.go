@@ -0,0 +1,24 @@
+package content
+
+import "context"
+
+type apiURLKey struct{}
+
+// ContextWithAPIURL returns a copy of ctx in which the apiUrl fields of the response are built from apiURL,
+// in the format scheme://host, instead of the one the service was created with.
+func ContextWithAPIURL(ctx context.Context, apiURL string) context.Context {
+	return context.WithValue(ctx, apiURLKey{}, apiURL)
+}
+
+// APIURLFromContext returns the API URL set with ContextWithAPIURL, if any.
+func APIURLFromContext(ctx context.Context) (string, bool) {
+	apiURL, ok := ctx.Value(apiURLKey{}).(string)
+	return apiURL, ok
+}
+
+func (cd *ConceptService) apiBaseURL(ctx context.Context) string {
+	if apiURL, ok := APIURLFromContext(ctx); ok {
+		return apiURL
+	}
+	return cd.apiURL
+}
@@ -0,0 +1,57 @@
+package content
+
+import (
+	"context"
+	"sync"
+)
+
+const defaultBatchConcurrency = 8
+
+// ConceptQuery asks for the content of one of the concepts in a batch.
+type ConceptQuery struct {
+	ConceptUUID string
+	Params      RequestParams
+}
+
+// ConceptQueryResult is the content found for one of the concepts in a batch, or the error finding it.
+type ConceptQueryResult struct {
+	ConceptContent
+	Err error
+}
+
+// WithBatchConcurrency limits how many of the queries of a batch run at the same time.
+func WithBatchConcurrency(concurrency int) ServiceOption {
+	return func(cd *ConceptService) {
+		if concurrency > 0 {
+			cd.batchConcurrency = concurrency
+		}
+	}
+}
+
+// GetContentForConcepts runs GetContentForConcept for each of the queries, at most batchConcurrency at a time.
+// The results are in the same order as the queries.
+func (cd *ConceptService) GetContentForConcepts(ctx context.Context, queries []ConceptQuery) []ConceptQueryResult {
+	results := make([]ConceptQueryResult, len(queries))
+	sem := make(chan struct{}, cd.batchConcurrency)
+
+	var wg sync.WaitGroup
+	for i, query := range queries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i].Err = ctx.Err()
+				return
+			}
+
+			result, err := cd.GetContentForConcept(ctx, query.ConceptUUID, query.Params)
+			results[i] = ConceptQueryResult{ConceptContent: result, Err: err}
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,127 @@
+package content
+
+import (
+	"context"
+	"errors"
+)
+
+// CooccurrenceParams filters the content concepts are found to co-occur in and the concepts returned.
+type CooccurrenceParams struct {
+	// FromDateEpoch and ToDateEpoch filter the content by its published date when Dated is set. Either can be the epoch.
+	FromDateEpoch int64
+	ToDateEpoch   int64
+	Dated         bool
+	Publication   []string
+	// ConceptTypes are labels of the co-occurring canonical concepts, e.g. Organisation.
+	ConceptTypes []string
+	// Predicates are the annotation predicates both concepts annotate the content with, e.g. about.
+	Predicates []string
+	Limit      int
+}
+
+// CooccurringConcept is a canonical concept annotating the same content as another one.
+// Similarity is the Jaccard index of the content of both concepts, i.e. Count over the content annotated with either.
+type CooccurringConcept struct {
+	ID         string  `json:"id"`
+	PrefLabel  string  `json:"prefLabel"`
+	Type       string  `json:"type"`
+	Count      int     `json:"count"`
+	Similarity float64 `json:"similarity"`
+}
+
+type cooccurrenceResult struct {
+	UUID       string   `json:"uuid"`
+	PrefLabel  string   `json:"prefLabel"`
+	Types      []string `json:"types"`
+	Count      int      `json:"count"`
+	Similarity float64  `json:"similarity"`
+}
+
+// GetCooccurringConcepts returns the canonical concepts annotating the most content along with the concept, most first.
+// Concepts are resolved through their concordance as GetContentForConcept does. It returns ErrConceptNotFound
+// when the concept does not exist.
+func (cd *ConceptService) GetCooccurringConcepts(ctx context.Context, conceptUUID string, params CooccurrenceParams) ([]CooccurringConcept, error) {
+	relationships, err := relationshipTypes(params.Predicates)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []cooccurrenceResult
+	query := cooccurringConceptsQuery(conceptUUID, params, relationships, &results)
+
+	info := queryInfo{endpoint: endpointCooccurringConcepts, conceptUUID: conceptUUID}
+	_, err = cd.read(ctx, info, query, false)
+	if errors.Is(err, errNoResults) {
+		if _, err = cd.noContentFound(ctx, conceptUUID); !errors.Is(err, ErrContentNotFound) {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	concepts := make([]CooccurringConcept, 0, len(results))
+	for _, result := range results {
+		concepts = append(concepts, CooccurringConcept{
+			ID:         idURL(result.UUID, cd.thingsURL),
+			PrefLabel:  result.PrefLabel,
+			Type:       mostSpecificType(result.Types),
+			Count:      result.Count,
+			Similarity: result.Similarity,
+		})
+	}
+	return concepts, nil
+}
+
+// cooccurringConceptsQuery ranks the co-occurring concepts first and only counts the content of the top ones,
+// which the similarity needs.
+func cooccurringConceptsQuery(conceptUUID string, params CooccurrenceParams, relationships []string, results *[]cooccurrenceResult) *Query {
+	filter, publication := contentFilter(params.Dated, params.Publication)
+	var predicateFilter, otherPredicateFilter, typeFilter string
+	if len(relationships) > 0 {
+		predicateFilter = " AND type(rel) IN $predicates"
+		otherPredicateFilter = " AND type(otherRel) IN $predicates"
+	}
+	if len(params.ConceptTypes) > 0 {
+		typeFilter = " AND any(label IN labels(other) WHERE label IN $types)"
+	}
+
+	return &Query{
+		Cypher: `
+			MATCH (:Concept{uuid:$conceptUUID})-[:EQUIVALENT_TO]->(canon:Concept)
+			MATCH (canon)<-[:EQUIVALENT_TO]-(:Concept)<-[rel]-(c:Content)
+			WHERE NOT 'LiveEvent' IN labels(c)` +
+			filter +
+			predicateFilter +
+			` WITH canon, collect(DISTINCT c) as contents
+			WITH canon, contents, size(contents) as conceptCount
+			UNWIND contents as c
+			MATCH (c)-[otherRel]->(:Concept)-[:EQUIVALENT_TO]->(other:Concept)
+			WHERE other <> canon` +
+			typeFilter +
+			otherPredicateFilter +
+			` WITH conceptCount, other, count(DISTINCT c) as count
+			ORDER BY count DESC, other.prefUUID
+			LIMIT $limit
+			CALL {
+				WITH other
+				MATCH (other)<-[:EQUIVALENT_TO]-(:Concept)<-[rel]-(c:Content)
+				WHERE NOT 'LiveEvent' IN labels(c)` +
+			filter +
+			predicateFilter +
+			` RETURN count(DISTINCT c) as otherCount
+			}
+			RETURN other.prefUUID as uuid, other.prefLabel as prefLabel, labels(other) as types, count,
+				toFloat(count) / (conceptCount + otherCount - count) as similarity
+			ORDER BY count DESC, uuid`,
+		Params: map[string]interface{}{
+			"conceptUUID": conceptUUID,
+			"fromDate":    params.FromDateEpoch,
+			"toDate":      params.ToDateEpoch,
+			"publication": publication,
+			"types":       params.ConceptTypes,
+			"predicates":  relationships,
+			"limit":       params.Limit,
+		},
+		Result: results,
+	}
+}
@@ -0,0 +1,175 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var ErrUnknownPredicate = errors.New("unknown predicate")
+
+// predicateRelationships are the relationships between content and concepts for each annotation predicate.
+var predicateRelationships = map[string]string{
+	"about":                   "ABOUT",
+	"hasAuthor":               "HAS_AUTHOR",
+	"hasContributor":          "HAS_CONTRIBUTOR",
+	"hasDisplayTag":           "HAS_DISPLAY_TAG",
+	"implicitlyAbout":         "IMPLICITLY_ABOUT",
+	"implicitlyClassifiedBy":  "IMPLICITLY_CLASSIFIED_BY",
+	"isClassifiedBy":          "IS_CLASSIFIED_BY",
+	"isPrimarilyClassifiedBy": "IS_PRIMARILY_CLASSIFIED_BY",
+	"majorMentions":           "MAJOR_MENTIONS",
+	"mentions":                "MENTIONS",
+}
+
+// Predicates returns the annotation predicates content can be filtered by.
+func Predicates() []string {
+	predicates := make([]string, 0, len(predicateRelationships))
+	for predicate := range predicateRelationships {
+		predicates = append(predicates, predicate)
+	}
+	sort.Strings(predicates)
+	return predicates
+}
+
+// relationshipTypes returns the relationships between content and concepts for the annotation predicates.
+func relationshipTypes(predicates []string) ([]string, error) {
+	relationships := make([]string, 0, len(predicates))
+	for _, predicate := range predicates {
+		relationship, found := predicateRelationships[predicate]
+		if !found {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownPredicate, predicate)
+		}
+		relationships = append(relationships, relationship)
+	}
+	return relationships, nil
+}
+
+// CountParams filters the content counted for a concept. Empty filters count all the content.
+type CountParams struct {
+	// FromDateEpoch and ToDateEpoch filter the content by its published date when Dated is set. Either can be the epoch.
+	FromDateEpoch int64
+	ToDateEpoch   int64
+	Dated         bool
+	Publication   []string
+	// ContentTypes are labels of the content, e.g. Article.
+	ContentTypes []string
+	// Predicates are the annotation predicates the content is annotated with, e.g. about.
+	Predicates []string
+	// Implicit counts the content GetContentForConceptImplicitly returns, narrowed only by the filters given.
+	// It neither defaults the publication nor leaves live events out, as that endpoint does not.
+	Implicit bool
+}
+
+type countResult struct {
+	ConceptUUID string `json:"conceptUUID"`
+	Count       int    `json:"count"`
+}
+
+// CountContentForConcepts returns how much content each of the concepts has, keyed by the concept UUIDs asked for.
+// Concepts that do not exist are left out.
+func (cd *ConceptService) CountContentForConcepts(ctx context.Context, conceptUUIDs []string, params CountParams) (map[string]int, error) {
+	relationships, err := relationshipTypes(params.Predicates)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []countResult
+	query := countContentForConceptsQuery(conceptUUIDs, params, relationships, &results)
+
+	info := queryInfo{endpoint: endpointContentCount}
+	_, err = cd.read(ctx, info, query, false)
+	if err != nil && !errors.Is(err, errNoResults) {
+		return nil, err
+	}
+
+	counts := make(map[string]int, len(results))
+	for _, result := range results {
+		counts[result.ConceptUUID] = result.Count
+	}
+	return counts, nil
+}
+
+func countContentForConceptsQuery(conceptUUIDs []string, params CountParams, relationships []string, results *[]countResult) *Query {
+	var filter string
+	publication := params.Publication
+	if params.Implicit {
+		filter = implicitCountFilter(params)
+	} else {
+		filter, publication = contentFilter(params.Dated, params.Publication)
+		filter = " AND NOT 'LiveEvent' IN labels(c)" + filter
+	}
+	if len(params.ContentTypes) > 0 {
+		filter += " AND any(label IN labels(c) WHERE label IN $types)"
+	}
+	if len(relationships) > 0 {
+		filter += " AND type(rel) IN $predicates"
+	}
+
+	var where string
+	if filter != "" {
+		where = "WHERE " + strings.TrimPrefix(filter, " AND ")
+	}
+
+	parameters := map[string]interface{}{
+		"conceptUUIDs": conceptUUIDs,
+		"fromDate":     params.FromDateEpoch,
+		"toDate":       params.ToDateEpoch,
+		"publication":  publication,
+		"types":        params.ContentTypes,
+		"predicates":   relationships,
+	}
+
+	if params.Implicit {
+		return &Query{
+			Cypher: `
+			UNWIND $conceptUUIDs as conceptUUID
+			MATCH (:Thing{uuid:conceptUUID})-[:EQUIVALENT_TO]->(canonicalConcept:Concept)
+			CALL {
+				WITH canonicalConcept
+				MATCH (canonicalConcept)<-[:EQUIVALENT_TO]-(leaf)
+				MATCH ` + narrowerPath + `
+				MATCH (narrowerLeaf)-[:EQUIVALENT_TO]->(narrowerCanonical)
+				RETURN narrowerCanonical
+				UNION
+				WITH canonicalConcept
+				MATCH (canonicalConcept)<-[:EQUIVALENT_TO]-(leaf)
+				MATCH ` + impliedPath + `
+				MATCH (narrowerLeaf)-[:EQUIVALENT_TO]->(narrowerCanonical)
+				RETURN narrowerCanonical
+			}
+			WITH DISTINCT conceptUUID, narrowerCanonical
+			OPTIONAL MATCH (narrowerCanonical)<-[:EQUIVALENT_TO]-(conceptLeaves)-[rel]-(c:Content)
+			` + where +
+				` RETURN conceptUUID, count(DISTINCT c) as count`,
+			Params: parameters,
+			Result: results,
+		}
+	}
+
+	return &Query{
+		Cypher: `
+			UNWIND $conceptUUIDs as conceptUUID
+			MATCH (:Concept{uuid:conceptUUID})-[:EQUIVALENT_TO]->(canon:Concept)
+			OPTIONAL MATCH (canon)<-[:EQUIVALENT_TO]-(leaves)<-[rel]-(c:Content)
+			` + where +
+			` RETURN conceptUUID, count(DISTINCT c) as count`,
+		Params: parameters,
+		Result: results,
+	}
+}
+
+// implicitCountFilter filters the implicit counts only by the dates and publications given, as
+// GetContentForConceptImplicitly does not filter its content by either.
+func implicitCountFilter(params CountParams) string {
+	var filter string
+	if params.Dated {
+		filter = " AND c.publishedDateEpoch > $fromDate AND c.publishedDateEpoch < $toDate"
+	}
+	if len(params.Publication) > 0 {
+		filter += " AND any(publication IN c.publication WHERE publication IN $publication)"
+	}
+	return filter
+}
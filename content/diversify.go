@@ -0,0 +1,144 @@
+package content
+
+import (
+	"context"
+	"errors"
+)
+
+const (
+	// MaxGroupedContent is how deep pages of grouped content can go, as page times limit. Every page up to the one
+	// asked for is filled first.
+	MaxGroupedContent = 1000
+
+	// groupedBatchSize is how much content is read at a time when filling pages of grouped content.
+	groupedBatchSize = 200
+	// groupedReadLimit is how much content is read at most when filling pages of grouped content. Once it is read,
+	// the pages are filled with it as if there was no more content.
+	groupedReadLimit = 5 * MaxGroupedContent
+)
+
+// GroupBy is what content is grouped by when capping how much of each group a page has.
+type GroupBy string
+
+const (
+	GroupByBrand GroupBy = "brand"
+	GroupByGenre GroupBy = "genre"
+	GroupByType  GroupBy = "type"
+)
+
+// GroupBys returns what content can be grouped by.
+func GroupBys() []GroupBy {
+	return []GroupBy{GroupByBrand, GroupByGenre, GroupByType}
+}
+
+// groupsExpression returns the groups content c is in. Content classified by several brands or genres is in each
+// of their groups, while content that is not classified by any is in none.
+func groupsExpression(groupBy GroupBy) string {
+	switch groupBy {
+	case GroupByBrand:
+		return "[(c)-[:IS_CLASSIFIED_BY|IS_PRIMARILY_CLASSIFIED_BY]->(:Brand)-[:EQUIVALENT_TO]->(group) | group.prefUUID]"
+	case GroupByGenre:
+		return "[(c)-[:IS_CLASSIFIED_BY|IS_PRIMARILY_CLASSIFIED_BY]->(:Genre)-[:EQUIVALENT_TO]->(group) | group.prefUUID]"
+	default:
+		return "[label IN labels(c) WHERE NOT label IN ['Thing', 'Content']]"
+	}
+}
+
+// diversifiedContent returns the content of the page when no page may have more than MaxPerGroup pieces of content
+// of any group. Pages are filled in turn with the content in the order of the sort, holding back the content of the
+// groups that are full for the following pages, where it goes ahead of later content. Every page is built the same
+// way whichever page is asked for, so pagination stays consistent. Pages are only short of ContentLimit once the
+// remaining content all belongs to full groups, or groupedReadLimit is reached. The profile is the one of the
+// first query. Pages are expected to go no deeper than MaxGroupedContent.
+func (cd *ConceptService) diversifiedContent(ctx context.Context, conceptUUID string, params RequestParams, profile bool) ([]contentResult, *QueryProfile, error) {
+	var (
+		// pending is the content not put on a page yet, in order.
+		pending      []contentResult
+		read         int
+		exhausted    bool
+		queryProfile *QueryProfile
+	)
+	readBatch := func() error {
+		var results []contentResult
+		query := contentForConceptQuery(conceptUUID, params, read, groupedBatchSize, cd.relevanceWeights, &results)
+
+		info := queryInfo{endpoint: endpointContent, conceptUUID: conceptUUID, params: &params}
+		batchProfile, err := cd.read(ctx, info, query, profile && read == 0)
+		if read == 0 {
+			queryProfile = batchProfile
+		}
+		if err != nil && !errors.Is(err, errNoResults) {
+			return err
+		}
+		pending = append(pending, results...)
+		read += len(results)
+		exhausted = len(results) < groupedBatchSize || read >= groupedReadLimit
+		return nil
+	}
+
+	page := max(params.Page, 1)
+	for p := 1; ; p++ {
+		var (
+			onPage []contentResult
+			held   []contentResult
+			counts = map[string]int{}
+		)
+		for i := 0; len(onPage) < params.ContentLimit; i++ {
+			if i == len(pending) {
+				if exhausted {
+					break
+				}
+				if err := readBatch(); err != nil {
+					return nil, queryProfile, err
+				}
+				if i == len(pending) {
+					break
+				}
+			}
+
+			result := pending[i]
+			if groupsFull(result.Groups, counts, params.MaxPerGroup) {
+				held = append(held, result)
+				continue
+			}
+			for _, group := range distinct(result.Groups) {
+				counts[group]++
+			}
+			onPage = append(onPage, result)
+			if len(onPage) == params.ContentLimit {
+				held = append(held, pending[i+1:]...)
+			}
+		}
+
+		if len(onPage) == 0 {
+			return nil, queryProfile, errNoResults
+		}
+		if p == page {
+			return onPage, queryProfile, nil
+		}
+		pending = held
+	}
+}
+
+// groupsFull reports whether any of the groups already has maxPerGroup pieces of content on the page.
+func groupsFull(groups []string, counts map[string]int, maxPerGroup int) bool {
+	for _, group := range groups {
+		if counts[group] >= maxPerGroup {
+			return true
+		}
+	}
+	return false
+}
+
+// distinct returns the groups without duplicates, as content can be classified through several concorded concepts.
+func distinct(groups []string) []string {
+	seen := make(map[string]bool, len(groups))
+	unique := make([]string, 0, len(groups))
+	for _, group := range groups {
+		if !seen[group] {
+			seen[group] = true
+			unique = append(unique, group)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,162 @@
+package content
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// errNoResults is returned by reads of queries with a Result that returned no rows.
+var errNoResults = errors.New("no results found")
+
+// Query is a Cypher query the ConceptService reads with. The rows it returns are decoded into Result,
+// a pointer to a slice of structs whose JSON field names are the returned columns. Result may be nil.
+type Query struct {
+	Cypher string
+	Params map[string]interface{}
+	Result interface{}
+}
+
+// Driver runs read transactions against a Neo4j database. It is safe for concurrent use.
+type Driver struct {
+	driver   neo4j.Driver
+	database string
+}
+
+// NewDriver creates a driver for the Neo4j instance at uri. It does not connect until it is used,
+// see VerifyConnectivity. An empty database reads from the default database of the server.
+func NewDriver(uri string, auth neo4j.AuthToken, database string, configurers ...func(*neo4j.Config)) (*Driver, error) {
+	driver, err := neo4j.NewDriver(uri, auth, configurers...)
+	if err != nil {
+		return nil, fmt.Errorf("creating neo4j driver: %w", err)
+	}
+	return &Driver{driver: driver, database: database}, nil
+}
+
+// VerifyConnectivity checks that the driver can reach Neo4j.
+func (d *Driver) VerifyConnectivity() error {
+	return d.driver.VerifyConnectivity()
+}
+
+// Close closes the connections of the driver.
+func (d *Driver) Close() error {
+	if d.driver == nil {
+		return nil
+	}
+	return d.driver.Close()
+}
+
+// txConfig configures the read transaction a query runs in.
+type txConfig struct {
+	// metadata is attached to the transaction, so it shows up in the database query log and listTransactions.
+	metadata map[string]interface{}
+	// observer, if set, is told how long the transaction waited for a connection and how long it held it.
+	observer PoolObserver
+}
+
+// read runs the query in a read transaction, decoding its rows into its Result.
+// It returns errNoResults when a query with a Result returns no rows.
+func (d *Driver) read(config txConfig, query *Query) error {
+	_, err := d.transaction(config, func(tx neo4j.Transaction) (interface{}, error) {
+		result, err := tx.Run(query.Cypher, query.Params)
+		if err != nil {
+			return nil, err
+		}
+		return nil, decodeRows(result, query)
+	})
+	return err
+}
+
+// readWithSummary runs the query in a read transaction and returns the summary of its execution
+// along with its rows.
+func (d *Driver) readWithSummary(config txConfig, query *Query) (neo4j.ResultSummary, error) {
+	var noRows bool
+	summary, err := d.transaction(config, func(tx neo4j.Transaction) (interface{}, error) {
+		result, err := tx.Run(query.Cypher, query.Params)
+		if err != nil {
+			return nil, err
+		}
+		err = decodeRows(result, query)
+		noRows = errors.Is(err, errNoResults)
+		if err != nil && !noRows {
+			return nil, err
+		}
+		return result.Consume()
+	})
+	if err != nil {
+		return nil, err
+	}
+	s, _ := summary.(neo4j.ResultSummary)
+	if noRows {
+		return s, errNoResults
+	}
+	return s, nil
+}
+
+// transaction runs work in a read transaction, with the retries of the neo4j driver.
+func (d *Driver) transaction(config txConfig, work neo4j.TransactionWork) (interface{}, error) {
+	session := d.driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead, DatabaseName: d.database})
+	defer session.Close()
+
+	if config.observer != nil {
+		// the driver takes a connection from the pool and begins the transaction before running work, so each run
+		// of work marks the end of a wait. A retry returns the connection and takes another one after a backoff.
+		start := time.Now()
+		var release func()
+		defer func() {
+			if release != nil {
+				release()
+			}
+		}()
+		observed := work
+		work = func(tx neo4j.Transaction) (interface{}, error) {
+			if release != nil {
+				release()
+			}
+			release = config.observer.ConnectionAcquired(time.Since(start))
+			defer func() { start = time.Now() }()
+			return observed(tx)
+		}
+	}
+
+	var configurers []func(*neo4j.TransactionConfig)
+	if len(config.metadata) > 0 {
+		configurers = append(configurers, neo4j.WithTxMetadata(config.metadata))
+	}
+	return session.ReadTransaction(work, configurers...)
+}
+
+// decodeRows decodes every row of the result into the Result of the query, by column name.
+func decodeRows(result neo4j.Result, query *Query) error {
+	records, err := result.Collect()
+	if err != nil {
+		return err
+	}
+	if query.Result == nil {
+		return nil
+	}
+
+	rows := make([]map[string]interface{}, 0, len(records))
+	for _, record := range records {
+		row := make(map[string]interface{}, len(record.Keys))
+		for i, key := range record.Keys {
+			row[key] = record.Values[i]
+		}
+		rows = append(rows, row)
+	}
+
+	encoded, err := json.Marshal(rows)
+	if err != nil {
+		return fmt.Errorf("encoding neo4j rows: %w", err)
+	}
+	if err = json.Unmarshal(encoded, query.Result); err != nil {
+		return fmt.Errorf("decoding neo4j rows: %w", err)
+	}
+	if len(rows) == 0 {
+		return errNoResults
+	}
+	return nil
+}
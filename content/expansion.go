@@ -0,0 +1,117 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The implicit queries expand a concept by following these paths from each of its leaves to the leaves of the narrower
+// concepts, whose content they return too. Paths include the leaf itself.
+const (
+	narrowerPath = `(leaf)<-[:HAS_BROADER|HAS_PARENT|IS_PART_OF*0..]-(narrowerLeaf)`
+	impliedPath  = `(leaf)-[:IMPLIED_BY*0..]->(narrowerLeaf)`
+)
+
+// subsidiaryPath follows organisations down to their subsidiaries, at most depth levels down.
+// The depth is part of the pattern as Cypher does not take parameters for the length of variable length relationships.
+func subsidiaryPath(depth int) string {
+	return fmt.Sprintf(`(leaf)<-[:SUB_ORGANISATION_OF*0..%d]-(narrowerLeaf)`, depth)
+}
+
+// implicitPaths returns the paths the implicit queries expand a concept by.
+func implicitPaths(params ImplicitParams) []string {
+	paths := []string{narrowerPath, impliedPath}
+	if params.SubsidiaryDepth > 0 {
+		paths = append(paths, subsidiaryPath(params.SubsidiaryDepth))
+	}
+	return paths
+}
+
+// ExpandedConcept is a canonical concept the implicit queries reach from another one, along with the shortest path
+// to it. Relationships are the types of the relationships on the path, from the concept expanded outwards.
+type ExpandedConcept struct {
+	ID            string   `json:"id"`
+	PrefLabel     string   `json:"prefLabel"`
+	Type          string   `json:"type"`
+	Relationships []string `json:"relationships"`
+	Depth         int      `json:"depth"`
+}
+
+type expansionResult struct {
+	UUID          string   `json:"uuid"`
+	PrefLabel     string   `json:"prefLabel"`
+	Types         []string `json:"types"`
+	Relationships []string `json:"relationships"`
+	Depth         int      `json:"depth"`
+}
+
+// GetConceptExpansion returns the canonical concepts GetContentForConceptImplicitly returns the content of,
+// including the concept itself at depth 0, shallowest first. It returns ErrConceptNotFound when the concept does not exist.
+func (cd *ConceptService) GetConceptExpansion(ctx context.Context, conceptUUID string, params ImplicitParams) ([]ExpandedConcept, error) {
+	var results []expansionResult
+	query := conceptExpansionQuery(conceptUUID, params, &results)
+
+	info := queryInfo{endpoint: endpointConceptExpansion, conceptUUID: conceptUUID}
+	_, err := cd.read(ctx, info, query, false)
+	if errors.Is(err, errNoResults) {
+		return nil, ErrConceptNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	concepts := make([]ExpandedConcept, 0, len(results))
+	for _, result := range results {
+		relationships := result.Relationships
+		if relationships == nil {
+			relationships = []string{}
+		}
+		concepts = append(concepts, ExpandedConcept{
+			ID:            idURL(result.UUID, cd.thingsURL),
+			PrefLabel:     result.PrefLabel,
+			Type:          mostSpecificType(result.Types),
+			Relationships: relationships,
+			Depth:         result.Depth,
+		})
+	}
+	return concepts, nil
+}
+
+// conceptExpansionQuery finds the distinct leaves each path reaches first, which Neo4j does without enumerating
+// the paths to them, and only then the shortest path to each of them. The leaves themselves are reached at depth 0.
+func conceptExpansionQuery(conceptUUID string, params ImplicitParams, results *[]expansionResult) *Query {
+	branches := []string{`
+				WITH leaf
+				MATCH path = (leaf)
+				RETURN leaf as narrowerLeaf, path`}
+	for _, path := range implicitPaths(params) {
+		branches = append(branches, `
+				WITH leaf
+				MATCH `+path+`
+				WITH DISTINCT leaf, narrowerLeaf
+				WHERE narrowerLeaf <> leaf
+				MATCH path = shortestPath(`+path+`)
+				RETURN narrowerLeaf, path`)
+	}
+
+	return &Query{
+		Cypher: `
+			MATCH (:Thing{uuid:$conceptUUID})-[:EQUIVALENT_TO]->(canonicalConcept:Concept)
+			MATCH (canonicalConcept)<-[:EQUIVALENT_TO]-(leaf)
+			CALL {` +
+			strings.Join(branches, `
+				UNION`) + `
+			}
+			MATCH (narrowerLeaf)-[:EQUIVALENT_TO]->(narrowerCanonical)
+			WITH narrowerCanonical, path
+			ORDER BY length(path)
+			WITH narrowerCanonical, head(collect(path)) as path
+			RETURN narrowerCanonical.prefUUID as uuid, narrowerCanonical.prefLabel as prefLabel, labels(narrowerCanonical) as types,
+				[rel IN relationships(path) | type(rel)] as relationships, length(path) as depth
+			ORDER BY depth, uuid`,
+		Params: map[string]interface{}{"conceptUUID": conceptUUID},
+		Result: results,
+	}
+}
@@ -0,0 +1,123 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// MaxHistogramBuckets caps how many buckets a histogram can have.
+const MaxHistogramBuckets = 1000
+
+const bucketDateLayout = "2006-01-02"
+
+var ErrTooManyBuckets = fmt.Errorf("histograms cannot have more than %d buckets", MaxHistogramBuckets)
+
+// histogramIntervals are the sizes of the buckets of a histogram, as understood by Cypher's date.truncate.
+// Weeks start on Monday.
+var histogramIntervals = []string{"day", "week", "month"}
+
+// Intervals returns the sizes the buckets of a histogram can have.
+func Intervals() []string {
+	return slices.Clone(histogramIntervals)
+}
+
+// HistogramParams selects the content counted in a histogram and how it is bucketed.
+// Both dates are required as every bucket between them is returned, including the empty ones.
+type HistogramParams struct {
+	Interval      string
+	FromDateEpoch int64
+	ToDateEpoch   int64
+	Publication   []string
+}
+
+// HistogramBucket is how much content was published in the interval starting on Date.
+type HistogramBucket struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
+
+// GetContentHistogram counts the content of the concept per interval, with the concordance and publication filtering
+// of GetContentForConcept. It returns ErrConceptNotFound when the concept does not exist.
+func (cd *ConceptService) GetContentHistogram(ctx context.Context, conceptUUID string, params HistogramParams) ([]HistogramBucket, error) {
+	buckets, err := histogramBuckets(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []HistogramBucket
+	query := contentHistogramQuery(conceptUUID, params, &results)
+
+	info := queryInfo{endpoint: endpointContentHistogram, conceptUUID: conceptUUID}
+	_, err = cd.read(ctx, info, query, false)
+	if errors.Is(err, errNoResults) {
+		if _, err = cd.noContentFound(ctx, conceptUUID); !errors.Is(err, ErrContentNotFound) {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int, len(results))
+	for _, result := range results {
+		counts[result.Date] = result.Count
+	}
+	for i := range buckets {
+		buckets[i].Count = counts[buckets[i].Date]
+	}
+	return buckets, nil
+}
+
+func contentHistogramQuery(conceptUUID string, params HistogramParams, results *[]HistogramBucket) *Query {
+	filter, publication := contentFilter(true, params.Publication)
+
+	return &Query{
+		Cypher: `
+			MATCH (:Concept{uuid:$conceptUUID})-[:EQUIVALENT_TO]->(canon:Concept)
+			MATCH (canon)<-[:EQUIVALENT_TO]-(leaves)<-[]-(c:Content)
+			WHERE NOT 'LiveEvent' IN labels(c)` +
+			filter +
+			` WITH DISTINCT c
+			RETURN toString(date.truncate($interval, datetime({epochSeconds: c.publishedDateEpoch}))) as date, count(c) as count`,
+		Params: map[string]interface{}{
+			"conceptUUID": conceptUUID,
+			"interval":    params.Interval,
+			"fromDate":    params.FromDateEpoch,
+			"toDate":      params.ToDateEpoch,
+			"publication": publication,
+		},
+		Result: results,
+	}
+}
+
+// histogramBuckets returns the empty buckets of every interval between the dates of the params.
+func histogramBuckets(params HistogramParams) ([]HistogramBucket, error) {
+	from := time.Unix(params.FromDateEpoch, 0).UTC()
+	to := time.Unix(params.ToDateEpoch, 0).UTC()
+
+	var next func(time.Time) time.Time
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	switch params.Interval {
+	case "day":
+		next = func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+	case "week":
+		start = start.AddDate(0, 0, -(int(start.Weekday())+6)%7)
+		next = func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }
+	case "month":
+		start = time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+		next = func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }
+	default:
+		return nil, fmt.Errorf("unknown interval %s", params.Interval)
+	}
+
+	var buckets []HistogramBucket
+	for t := start; t.Before(to); t = next(t) {
+		if len(buckets) == MaxHistogramBuckets {
+			return nil, ErrTooManyBuckets
+		}
+		buckets = append(buckets, HistogramBucket{Date: t.Format(bucketDateLayout)})
+	}
+	return buckets, nil
+}
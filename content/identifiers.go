@@ -0,0 +1,71 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrUnknownAuthority    = errors.New("unknown authority")
+	ErrAmbiguousIdentifier = errors.New("identifier matches more than one concept")
+)
+
+// identifierLabels are the labels of the identifier nodes of each authority concepts can be looked up by.
+var identifierLabels = map[string]string{
+	"FACTSET":    "FactsetIdentifier",
+	"Smartlogic": "SmartlogicIdentifier",
+	"TME":        "TMEIdentifier",
+	"Wikidata":   "WikidataIdentifier",
+}
+
+// Authorities returns the authorities whose identifiers concepts can be looked up by.
+func Authorities() []string {
+	authorities := make([]string, 0, len(identifierLabels))
+	for authority := range identifierLabels {
+		authorities = append(authorities, authority)
+	}
+	sort.Strings(authorities)
+	return authorities
+}
+
+// ConceptUUIDForIdentifier returns the UUID of the canonical concept the authority identifies with identifier.
+// Authorities are matched case-insensitively. Identifiers of more than one canonical concept are rejected
+// with ErrAmbiguousIdentifier rather than resolved to either of them.
+func (cd *ConceptService) ConceptUUIDForIdentifier(ctx context.Context, authority, identifier string) (string, error) {
+	label := ""
+	for name, l := range identifierLabels {
+		if strings.EqualFold(name, authority) {
+			label = l
+		}
+	}
+	if label == "" {
+		return "", ErrUnknownAuthority
+	}
+
+	var results []contentResult
+	query := &Query{
+		// the label comes from identifierLabels, never from the request
+		Cypher: fmt.Sprintf(`
+			MATCH (:%s{value:$identifier})-[:IDENTIFIES]->(:Concept)-[:EQUIVALENT_TO]->(canon:Concept)
+			RETURN DISTINCT canon.prefUUID as canonicalUUID
+			LIMIT 2`, label),
+		Params: map[string]interface{}{"identifier": identifier},
+		Result: &results,
+	}
+
+	info := queryInfo{endpoint: endpointConceptIdentifier}
+	_, err := cd.read(ctx, info, query, false)
+	if errors.Is(err, errNoResults) {
+		return "", ErrConceptNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(results) > 1 {
+		return "", ErrAmbiguousIdentifier
+	}
+	return results[0].CanonicalUUID, nil
+}
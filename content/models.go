@@ -1,5 +1,7 @@
 package content
 
+import "encoding/json"
+
 const (
 	ThingsPrefix = "http://www.ft.com/things/"
 )
@@ -8,4 +10,80 @@ type Content struct {
 	ID          string   `json:"id"`
 	APIURL      string   `json:"apiUrl"`
 	Publication []string `json:"publication,omitempty"`
+	// Annotations are the predicates the content is annotated with the concept by, when asked for.
+	// They are nil when not asked for and empty when the content has none.
+	Annotations []string `json:"annotations,omitempty"`
+}
+
+// MarshalJSON leaves out the annotations only when they were not asked for, so that content without any
+// comes with an empty list rather than none at all.
+func (c Content) MarshalJSON() ([]byte, error) {
+	type content Content
+	if c.Annotations == nil {
+		return json.Marshal(content(c))
+	}
+	return json.Marshal(struct {
+		content
+		Annotations []string `json:"annotations"`
+	}{content(c), c.Annotations})
+}
+
+// Concept is the canonical concept content was found for.
+type Concept struct {
+	ID           string   `json:"id"`
+	PrefLabel    string   `json:"prefLabel"`
+	Type         string   `json:"type"`
+	ConcordedIDs []string `json:"concordedIds"`
+}
+
+// ConceptContent is the content found for a concept along with the canonical concept it was resolved to.
+type ConceptContent struct {
+	CanonicalUUID string
+	Concept       *Concept
+	Content       []Content
+}
+
+// conceptTypeParents is the concept type hierarchy, used to tell the type of a concept from its labels.
+var conceptTypeParents = map[string]string{
+	"Concept":                     "Thing",
+	"Classification":              "Concept",
+	"AlphavilleSeries":            "Classification",
+	"Brand":                       "Classification",
+	"Genre":                       "Classification",
+	"Section":                     "Classification",
+	"SpecialReport":               "Classification",
+	"Subject":                     "Classification",
+	"IndustryClassification":      "Classification",
+	"NAICSIndustryClassification": "IndustryClassification",
+	"Location":                    "Concept",
+	"Topic":                       "Concept",
+	"Person":                      "Concept",
+	"Organisation":                "Concept",
+	"Company":                     "Organisation",
+	"PublicCompany":               "Company",
+	"PrivateCompany":              "Company",
+	"FinancialInstrument":         "Concept",
+	"Membership":                  "Concept",
+	"Role":                        "Concept",
+	"MembershipRole":              "Role",
+	"BoardRole":                   "MembershipRole",
+}
+
+// mostSpecificType returns the deepest of the labels in the concept type hierarchy.
+// Labels missing from the hierarchy are taken to be more specific than the known ones.
+func mostSpecificType(labels []string) string {
+	mostSpecific, maxDepth := "", -1
+	for _, label := range labels {
+		depth := 0
+		if _, known := conceptTypeParents[label]; !known && label != "Thing" {
+			depth = len(conceptTypeParents) + 1
+		}
+		for parent, ok := conceptTypeParents[label]; ok; parent, ok = conceptTypeParents[parent] {
+			depth++
+		}
+		if depth > maxDepth || (depth == maxDepth && label < mostSpecific) {
+			mostSpecific, maxDepth = label, depth
+		}
+	}
+	return mostSpecific
 }
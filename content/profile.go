@@ -0,0 +1,72 @@
+package content
+
+import (
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// QueryProfile describes how Neo4j executed a query run with PROFILE.
+type QueryProfile struct {
+	Cypher     string                 `json:"cypher"`
+	Parameters map[string]interface{} `json:"parameters"`
+	DBHits     int64                  `json:"dbHits"`
+	Rows       int64                  `json:"rows"`
+	Timings    QueryTimings           `json:"timings"`
+	Plan       *PlanOperator          `json:"plan,omitempty"`
+}
+
+// QueryTimings are reported in milliseconds.
+type QueryTimings struct {
+	ResultAvailableAfter int64 `json:"resultAvailableAfterMs"`
+	ResultConsumedAfter  int64 `json:"resultConsumedAfterMs"`
+	Total                int64 `json:"totalMs"`
+}
+
+// PlanOperator is a single operator of a profiled query plan.
+type PlanOperator struct {
+	Operator    string                 `json:"operator"`
+	Identifiers []string               `json:"identifiers,omitempty"`
+	Arguments   map[string]interface{} `json:"arguments,omitempty"`
+	DBHits      int64                  `json:"dbHits"`
+	Rows        int64                  `json:"rows"`
+	Children    []*PlanOperator        `json:"children,omitempty"`
+}
+
+func newQueryProfile(query *Query, summary neo4j.ResultSummary) *QueryProfile {
+	profile := &QueryProfile{
+		Cypher:     query.Cypher,
+		Parameters: query.Params,
+		Timings: QueryTimings{
+			ResultAvailableAfter: summary.ResultAvailableAfter().Milliseconds(),
+			ResultConsumedAfter:  summary.ResultConsumedAfter().Milliseconds(),
+		},
+	}
+
+	if plan := summary.Profile(); plan != nil {
+		profile.Plan = newPlanOperator(plan)
+		profile.DBHits = profile.Plan.totalDBHits()
+		profile.Rows = plan.Records()
+	}
+	return profile
+}
+
+func newPlanOperator(plan neo4j.ProfiledPlan) *PlanOperator {
+	op := &PlanOperator{
+		Operator:    plan.Operator(),
+		Identifiers: plan.Identifiers(),
+		Arguments:   plan.Arguments(),
+		DBHits:      plan.DbHits(),
+		Rows:        plan.Records(),
+	}
+	for _, child := range plan.Children() {
+		op.Children = append(op.Children, newPlanOperator(child))
+	}
+	return op
+}
+
+func (op *PlanOperator) totalDBHits() int64 {
+	hits := op.DBHits
+	for _, child := range op.Children {
+		hits += child.totalDBHits()
+	}
+	return hits
+}
@@ -0,0 +1,180 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+
+	transactionidutils "github.com/Financial-Times/transactionid-utils-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/Financial-Times/public-content-by-concept-api/v2/content")
+
+// Endpoints identify what a query is run for in slow query logs and metrics.
+const (
+	endpointContent             = "content"
+	endpointImplicitContent     = "content-implicitly"
+	endpointContentCount        = "content-count"
+	endpointContentHistogram    = "content-histogram"
+	endpointTrendingConcepts    = "trending-concepts"
+	endpointCooccurringConcepts = "cooccurring-concepts"
+	endpointConceptExpansion    = "concept-expansion"
+	endpointConceptExists       = "concept-exists"
+	endpointCanonicalConcept    = "canonical-concept"
+	endpointConceptIdentifier   = "concept-identifier"
+	endpointSchema              = "schema-check"
+)
+
+// QueryObserver is notified about the queries the ConceptService runs against Neo4j.
+type QueryObserver interface {
+	// QueryStarted is called before a query is run. The returned func is called once it completes
+	// with the number of rows returned and the error, if any.
+	QueryStarted(queryType string) func(rows int, err error)
+}
+
+// PoolObserver is notified about the connections the ConceptService takes from the pool of the neo4j driver.
+type PoolObserver interface {
+	// ConnectionAcquired is called once a transaction has got a connection, with how long it waited for it.
+	// The returned func is called once the transaction is done with the connection.
+	ConnectionAcquired(wait time.Duration) (release func())
+}
+
+// queryInfo is the context a query is run in, used when reporting on it.
+type queryInfo struct {
+	endpoint    string
+	conceptUUID string
+	params      *RequestParams
+}
+
+// read runs the query against Neo4j with the transaction ID from ctx attached as transaction metadata.
+// When profile is true the query is run with PROFILE and its plan is returned.
+func (cd *ConceptService) read(ctx context.Context, info queryInfo, query *Query, profile bool) (*QueryProfile, error) {
+	driver, err := cd.db()
+	if err != nil {
+		return nil, err
+	}
+
+	transID, _ := transactionidutils.GetTransactionIDFromContext(ctx)
+
+	_, span := tracer.Start(ctx, "neo4j "+info.endpoint,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(info.attributes()...),
+	)
+	defer span.End()
+
+	var queryDone func(rows int, err error)
+	if cd.queryObserver != nil {
+		queryDone = cd.queryObserver.QueryStarted(info.endpoint)
+	}
+
+	start := time.Now()
+	queryProfile, err := cd.runRead(driver, transID, info, query, profile)
+	duration := time.Since(start)
+
+	if queryDone != nil {
+		queryDone(resultCount(query), err)
+	}
+	cd.logSlowQuery(transID, info, query, duration)
+
+	span.SetAttributes(attribute.Int("db.rows", resultCount(query)))
+	if err != nil && !errors.Is(err, errNoResults) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	if queryProfile != nil {
+		queryProfile.Timings.Total = duration.Milliseconds()
+	}
+	return queryProfile, err
+}
+
+func (cd *ConceptService) runRead(driver *Driver, transID string, info queryInfo, query *Query, profile bool) (*QueryProfile, error) {
+	config := txConfig{observer: cd.poolObserver}
+	if transID != "" {
+		config.metadata = txMetadata(transID, info)
+	}
+	if !profile {
+		return nil, driver.read(config, query)
+	}
+
+	profiled := *query
+	profiled.Cypher = "PROFILE " + query.Cypher
+	summary, err := driver.readWithSummary(config, &profiled)
+	if summary == nil {
+		return nil, err
+	}
+	return newQueryProfile(query, summary), err
+}
+
+// attributes describe the query in its trace span.
+func (info queryInfo) attributes() []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		semconv.DBSystemNeo4j,
+		attribute.String("query.type", info.endpoint),
+	}
+	if info.conceptUUID != "" {
+		attrs = append(attrs, attribute.String("concept.uuid", info.conceptUUID))
+	}
+	if p := info.params; p != nil {
+		attrs = append(attrs,
+			attribute.Int("request.page", p.Page),
+			attribute.Int("request.limit", p.ContentLimit),
+			attribute.Int64("request.from_date_epoch", p.FromDateEpoch),
+			attribute.Int64("request.to_date_epoch", p.ToDateEpoch),
+			attribute.StringSlice("request.publication", p.Publication),
+			attribute.String("request.sort", string(p.Sort)),
+			attribute.Int("request.max_per_group", p.MaxPerGroup),
+			attribute.String("request.group_by", string(p.GroupBy)),
+			attribute.Bool("request.include_annotations", p.IncludeAnnotations),
+		)
+	}
+	return attrs
+}
+
+// txMetadata is the metadata of the Neo4j transaction a query is run in, so the transaction ID shows up in the database query log.
+func txMetadata(transID string, info queryInfo) map[string]interface{} {
+	return map[string]interface{}{
+		"transaction_id": transID,
+		"endpoint":       info.endpoint,
+		"concept_uuid":   info.conceptUUID,
+	}
+}
+
+func (cd *ConceptService) logSlowQuery(transID string, info queryInfo, query *Query, duration time.Duration) {
+	if cd.log == nil || cd.slowQueryThreshold <= 0 || duration < cd.slowQueryThreshold {
+		return
+	}
+
+	fields := map[string]interface{}{
+		"endpoint":   info.endpoint,
+		"rows":       resultCount(query),
+		"durationMs": duration.Milliseconds(),
+	}
+	if info.params != nil {
+		fields["requestParams"] = *info.params
+	}
+
+	entry := cd.log.WithTransactionID(transID).WithFields(fields)
+	if info.conceptUUID != "" {
+		entry = entry.WithUUID(info.conceptUUID)
+	}
+	entry.Warnf("Slow neo4j query took %s", duration)
+}
+
+// resultCount returns the number of rows mapped into the query result.
+func resultCount(query *Query) int {
+	if query.Result == nil {
+		return 0
+	}
+	v := reflect.Indirect(reflect.ValueOf(query.Result))
+	if v.Kind() != reflect.Slice {
+		return 0
+	}
+	return v.Len()
+}
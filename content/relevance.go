@@ -0,0 +1,105 @@
+package content
+
+import (
+	"time"
+)
+
+// Sort is the order content is returned in.
+type Sort string
+
+const (
+	// SortNewest returns the most recently published content first. It is the default.
+	SortNewest Sort = "desc"
+	// SortOldest returns content in the order it was published, e.g. for timelines.
+	SortOldest Sort = "asc"
+	// SortRelevance returns the content most relevant to the concept first, as scored with the RelevanceWeights.
+	SortRelevance Sort = "relevance"
+)
+
+// Sorts returns the orders content can be returned in.
+func Sorts() []Sort {
+	return []Sort{SortNewest, SortOldest, SortRelevance}
+}
+
+// RelevanceWeights score how relevant a piece of content is to a concept. The score adds up the weight of the
+// strongest predicate the content is annotated with and the Leaves weight for each of the concorded concepts
+// annotating it, and halves for every HalfLife since the content was published.
+type RelevanceWeights struct {
+	// Predicates weigh the annotation predicates, e.g. about. Predicates left out weigh nothing.
+	Predicates map[string]float64
+	Leaves     float64
+	HalfLife   time.Duration
+}
+
+// DefaultRelevanceWeights rank content about the concept above content displaying it as a tag, which ranks above
+// content mentioning it, and halve the score of content a week older.
+var DefaultRelevanceWeights = RelevanceWeights{
+	Predicates: map[string]float64{
+		"about":         3,
+		"hasDisplayTag": 2,
+		"mentions":      1,
+	},
+	Leaves:   1,
+	HalfLife: 7 * 24 * time.Hour,
+}
+
+// WithRelevanceWeights scores content sorted by relevance with weights instead of DefaultRelevanceWeights.
+// Predicates are expected to be ones returned by Predicates. Weights without a positive HalfLife are ignored.
+func WithRelevanceWeights(weights RelevanceWeights) ServiceOption {
+	return func(cd *ConceptService) {
+		if weights.HalfLife > 0 {
+			cd.relevanceWeights = weights
+		}
+	}
+}
+
+// relationshipWeights returns the weights of the relationships between content and concepts for the predicates.
+func (w RelevanceWeights) relationshipWeights() map[string]interface{} {
+	weights := make(map[string]interface{}, len(w.Predicates))
+	for predicate, weight := range w.Predicates {
+		if relationship, found := predicateRelationships[predicate]; found {
+			weights[relationship] = weight
+		}
+	}
+	return weights
+}
+
+// orderBy returns the clauses ordering content c annotated through rel with the leaves for the sort, leaving one row
+// per content along with the variables in keep, and the types of its relationships as relationships when asked for.
+// Relevance expects the parameters returned by RelevanceWeights.params.
+func orderBy(sort Sort, keep string, relationships bool) string {
+	var collect string
+	if relationships {
+		collect = ", " + relationshipsAggregate
+	}
+
+	switch sort {
+	case SortOldest:
+		return ` WITH DISTINCT c, ` + keep + collect + `
+			ORDER BY c.publishedDateEpoch ASC`
+	case SortRelevance:
+		scored := keep
+		if relationships {
+			scored += ", relationships"
+		}
+		// Recency decays relative to now so the scores stay within range. As every score decays by the same factor
+		// over time, the order does not depend on now and pages stay consistent between requests.
+		return ` WITH c, ` + keep + `, max(coalesce($predicateWeights[type(rel)], 0.0)) as predicateWeight, count(DISTINCT leaves) as leafCount` + collect + `
+			WITH c, ` + scored + `,
+				(predicateWeight + $leafWeight * leafCount) * 0.5 ^ (($now - coalesce(c.publishedDateEpoch, 0)) / $halfLife) as relevance
+			ORDER BY relevance DESC, c.publishedDateEpoch DESC`
+	default:
+		return ` WITH DISTINCT c, ` + keep + collect + `
+			ORDER BY c.publishedDateEpoch DESC`
+	}
+}
+
+// params returns the parameters of the relevance score.
+func (w RelevanceWeights) params(now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"predicateWeights": w.relationshipWeights(),
+		"leafWeight":       w.Leaves,
+		"now":              now.Unix(),
+		"halfLife":         w.HalfLife.Seconds(),
+	}
+}
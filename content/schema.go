@@ -0,0 +1,123 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const indexStateOnline = "ONLINE"
+
+// IndexDefinition is a single property index the content queries rely on.
+// Uniqueness constraints satisfy the requirement as they are backed by an index.
+type IndexDefinition struct {
+	Label    string
+	Property string
+}
+
+func (i IndexDefinition) String() string {
+	return i.Label + "." + i.Property
+}
+
+// RequiredIndexes lists the indexes without which the content queries fall back to label scans.
+var RequiredIndexes = []IndexDefinition{
+	{Label: "Concept", Property: "uuid"},
+	{Label: "Thing", Property: "uuid"},
+	{Label: "Content", Property: "publishedDateEpoch"},
+}
+
+// SchemaStatus is the outcome of checking RequiredIndexes against the database.
+type SchemaStatus struct {
+	CheckedAt time.Time
+	// Missing lists the required indexes that do not exist.
+	Missing []IndexDefinition
+	// NotOnline maps the required indexes that exist but cannot be used yet, e.g. populating or failed, to their state.
+	NotOnline map[IndexDefinition]string
+}
+
+// OK reports whether all required indexes exist and are online.
+func (s SchemaStatus) OK() bool {
+	return len(s.Missing) == 0 && len(s.NotOnline) == 0
+}
+
+func (s SchemaStatus) String() string {
+	if s.OK() {
+		return "All required indexes are online"
+	}
+
+	var problems []string
+	for _, idx := range s.Missing {
+		problems = append(problems, fmt.Sprintf("%s is missing", idx))
+	}
+	for _, idx := range RequiredIndexes {
+		if state, ok := s.NotOnline[idx]; ok {
+			problems = append(problems, fmt.Sprintf("%s is %s", idx, state))
+		}
+	}
+	return strings.Join(problems, ", ")
+}
+
+// CheckSchema verifies that RequiredIndexes exist and are online. The result is kept for SchemaHealth.
+func (cd *ConceptService) CheckSchema() (SchemaStatus, error) {
+	var results []struct {
+		LabelsOrTypes []string `json:"labelsOrTypes"`
+		Properties    []string `json:"properties"`
+		State         string   `json:"state"`
+	}
+
+	query := &Query{
+		Cypher: `SHOW INDEXES YIELD labelsOrTypes, properties, state
+			WHERE labelsOrTypes IS NOT NULL
+			RETURN labelsOrTypes, properties, state`,
+		Result: &results,
+	}
+
+	_, err := cd.read(context.Background(), queryInfo{endpoint: endpointSchema}, query, false)
+	if err != nil && !errors.Is(err, errNoResults) {
+		return SchemaStatus{}, err
+	}
+
+	status := SchemaStatus{
+		CheckedAt: time.Now(),
+		NotOnline: map[IndexDefinition]string{},
+	}
+	for _, required := range RequiredIndexes {
+		found := false
+		state := ""
+		for _, r := range results {
+			if slices.Equal(r.LabelsOrTypes, []string{required.Label}) && slices.Equal(r.Properties, []string{required.Property}) {
+				// a property can be covered by more than one index, e.g. a constraint and a plain index
+				if !found || r.State == indexStateOnline {
+					state = r.State
+				}
+				found = true
+			}
+		}
+
+		switch {
+		case !found:
+			status.Missing = append(status.Missing, required)
+		case state != indexStateOnline:
+			status.NotOnline[required] = state
+		}
+	}
+
+	cd.schemaStatus.Store(&status)
+	return status, nil
+}
+
+// SchemaHealth reports the outcome of the last CheckSchema call in the format expected by health checks.
+// Indexes that have not been checked yet, e.g. while Neo4j is unreachable, are reported as unhealthy.
+func (cd *ConceptService) SchemaHealth() (string, error) {
+	status := cd.schemaStatus.Load()
+	if status == nil {
+		return "", errors.New("required indexes have not been checked yet")
+	}
+	if !status.OK() {
+		return "", errors.New(status.String())
+	}
+	return status.String(), nil
+}
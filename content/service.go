@@ -1,24 +1,51 @@
 package content
 
 import (
+	"context"
 	"errors"
 	"net/url"
 	"slices"
 	"strings"
+	"sync/atomic"
+	"time"
 
-	cmneo4j "github.com/Financial-Times/cm-neo4j-driver"
+	"github.com/Financial-Times/go-logger/v2"
 )
 
 const (
 	ftPinkPublication = "88fdde6c-2aa4-4f78-af02-9f680097cfd6"
 )
 
-var ErrContentNotFound = errors.New("content not found")
+var (
+	ErrContentNotFound = errors.New("content not found")
+	ErrConceptNotFound = errors.New("concept not found")
+	ErrNotConnected    = errors.New("not connected to neo4j")
+)
 
 // ConceptService interacts with Neo4j db to extract content by concept information
 type ConceptService struct {
-	driver *cmneo4j.Driver
-	apiURL string
+	driver       atomic.Pointer[Driver]
+	apiURL       string
+	thingsURL    string
+	schemaStatus atomic.Pointer[SchemaStatus]
+
+	log                *logger.UPPLogger
+	slowQueryThreshold time.Duration
+	queryObserver      QueryObserver
+	poolObserver       PoolObserver
+	batchConcurrency   int
+	relevanceWeights   RelevanceWeights
+}
+
+// ServiceOption configures optional behaviour of the ConceptService.
+type ServiceOption func(*ConceptService)
+
+// WithSlowQueryLogging logs a warning for every query that takes longer than threshold.
+func WithSlowQueryLogging(log *logger.UPPLogger, threshold time.Duration) ServiceOption {
+	return func(cd *ConceptService) {
+		cd.log = log
+		cd.slowQueryThreshold = threshold
+	}
 }
 
 type RequestParams struct {
@@ -27,157 +54,383 @@ type RequestParams struct {
 	FromDateEpoch int64
 	ToDateEpoch   int64
 	Publication   []string
+	Sort          Sort
+	// MaxPerGroup caps how much of the content of each GroupBy group a page has. Zero leaves the content ungrouped.
+	MaxPerGroup int
+	GroupBy     GroupBy
+	// IncludeAnnotations returns the predicates each piece of content is annotated with the concept by.
+	IncludeAnnotations bool
+}
+
+// ImplicitParams selects what GetContentForConceptImplicitly expands a concept to besides its narrower concepts.
+type ImplicitParams struct {
+	// SubsidiaryDepth includes the subsidiaries of an organisation down to this many levels. Zero leaves them out.
+	SubsidiaryDepth int
+	// IncludeAnnotations returns the predicates each piece of content is annotated with the concepts it was found through by.
+	IncludeAnnotations bool
+}
+
+// WithFTURL builds the id fields of the response from ftURL, in the format scheme://host, instead of http://www.ft.com.
+func WithFTURL(ftURL string) ServiceOption {
+	return func(cd *ConceptService) {
+		cd.thingsURL = strings.TrimRight(ftURL, "/") + "/things/"
+	}
+}
+
+// WithQueryObserver reports every query sent to Neo4j to the observer, e.g. to record metrics.
+func WithQueryObserver(observer QueryObserver) ServiceOption {
+	return func(cd *ConceptService) {
+		cd.queryObserver = observer
+	}
+}
+
+// WithPoolObserver reports every connection the queries take from the driver pool to the observer, e.g. to record metrics.
+func WithPoolObserver(observer PoolObserver) ServiceOption {
+	return func(cd *ConceptService) {
+		cd.poolObserver = observer
+	}
 }
 
-func NewContentByConceptService(driver *cmneo4j.Driver, apiURL string) (*ConceptService, error) {
+// NewContentByConceptService creates a ConceptService. The driver may be nil when Neo4j is not reachable yet,
+// in which case queries fail with ErrNotConnected until SetDriver is called.
+func NewContentByConceptService(driver *Driver, apiURL string, opts ...ServiceOption) (*ConceptService, error) {
 	_, err := url.ParseRequestURI(apiURL)
 	if err != nil {
 		return nil, err
 	}
 
-	return &ConceptService{
-		driver: driver,
-		apiURL: apiURL,
-	}, nil
+	cd := &ConceptService{
+		apiURL:           apiURL,
+		thingsURL:        ThingsPrefix,
+		batchConcurrency: defaultBatchConcurrency,
+		relevanceWeights: DefaultRelevanceWeights,
+	}
+	for _, opt := range opts {
+		opt(cd)
+	}
+	if _, err = url.ParseRequestURI(cd.thingsURL); err != nil {
+		return nil, err
+	}
+	cd.driver.Store(driver)
+	return cd, nil
+}
+
+// SetDriver makes the service use the given driver for all subsequent queries.
+func (cd *ConceptService) SetDriver(driver *Driver) {
+	cd.driver.Store(driver)
+}
+
+// Connected reports whether the service has a Neo4j driver to run queries with.
+func (cd *ConceptService) Connected() bool {
+	return cd.driver.Load() != nil
 }
 
 func (cd *ConceptService) CheckConnection() (string, error) {
-	err := cd.driver.VerifyConnectivity()
+	driver, err := cd.db()
+	if err != nil {
+		return "Could not connect to database!", err
+	}
+	err = driver.VerifyConnectivity()
 	if err != nil {
 		return "Could not connect to database!", err
 	}
 	return "Database connection is OK", nil
 }
 
-func (cd *ConceptService) GetContentForConcept(conceptUUID string, params RequestParams) ([]Content, error) {
-	var results []struct {
-		UUID        string   `json:"uuid"`
-		Types       []string `json:"types"`
-		Publication []string `json:"publication"`
+func (cd *ConceptService) db() (*Driver, error) {
+	driver := cd.driver.Load()
+	if driver == nil {
+		return nil, ErrNotConnected
 	}
+	return driver, nil
+}
 
-	var dateFilter string
-	if params.FromDateEpoch > 0 && params.ToDateEpoch > 0 {
-		dateFilter = " AND c.publishedDateEpoch > $fromDate AND c.publishedDateEpoch < $toDate"
-	}
+type contentResult struct {
+	UUID               string   `json:"uuid"`
+	Types              []string `json:"types"`
+	Publication        []string `json:"publication"`
+	CanonicalUUID      string   `json:"canonicalUUID"`
+	CanonicalPrefLabel string   `json:"canonicalPrefLabel"`
+	CanonicalTypes     []string `json:"canonicalTypes"`
+	LeafUUIDs          []string `json:"leafUUIDs"`
+	Groups             []string `json:"groups"`
+	Relationships      []string `json:"relationships"`
+}
+
+func (cd *ConceptService) GetContentForConcept(ctx context.Context, conceptUUID string, params RequestParams) (ConceptContent, error) {
+	result, _, err := cd.getContentForConcept(ctx, conceptUUID, params, false)
+	return result, err
+}
 
-	// skipCount determines how many rows to skip before returning the results
-	skipCount := 0
-	if params.Page > 1 {
-		skipCount = (params.Page - 1) * params.ContentLimit
+// ProfileContentForConcept runs the GetContentForConcept query with PROFILE and returns its plan along with the content.
+func (cd *ConceptService) ProfileContentForConcept(ctx context.Context, conceptUUID string, params RequestParams) (ConceptContent, *QueryProfile, error) {
+	return cd.getContentForConcept(ctx, conceptUUID, params, true)
+}
+
+func (cd *ConceptService) getContentForConcept(ctx context.Context, conceptUUID string, params RequestParams, profile bool) (ConceptContent, *QueryProfile, error) {
+	var (
+		results      []contentResult
+		queryProfile *QueryProfile
+		err          error
+	)
+	if params.MaxPerGroup > 0 {
+		results, queryProfile, err = cd.diversifiedContent(ctx, conceptUUID, params, profile)
+	} else {
+		// skipCount determines how many rows to skip before returning the results
+		skipCount := 0
+		if params.Page > 1 {
+			skipCount = (params.Page - 1) * params.ContentLimit
+		}
+		query := contentForConceptQuery(conceptUUID, params, skipCount, params.ContentLimit, cd.relevanceWeights, &results)
+
+		info := queryInfo{endpoint: endpointContent, conceptUUID: conceptUUID, params: &params}
+		queryProfile, err = cd.read(ctx, info, query, profile)
+	}
+	if errors.Is(err, errNoResults) {
+		result, err := cd.noContentFound(ctx, conceptUUID)
+		return result, queryProfile, err
+	}
+	if err != nil {
+		return ConceptContent{}, queryProfile, err
 	}
 
-	var publicationFilter string
-	if len(params.Publication) == 0 {
-		// default to FT Pink if no publication param is supplied
-		params.Publication = []string{ftPinkPublication}
+	cntList := make([]Content, 0)
+	for _, result := range results {
+		cnt := Content{
+			ID:          idURL(result.UUID, cd.thingsURL),
+			APIURL:      apiURL(result.UUID, cd.apiBaseURL(ctx)),
+			Publication: result.Publication,
+		}
+		if params.IncludeAnnotations {
+			cnt.Annotations = annotationPredicates(result.Relationships)
+		}
+		cntList = append(cntList, cnt)
 	}
 
-	if slices.Contains(params.Publication, ftPinkPublication) {
-		// include the old records that do not have publication field when publication filter is supplied
-		publicationFilter = " AND (c.publication IS NULL OR any(publication IN c.publication WHERE publication IN $publication))"
-	} else {
-		publicationFilter = " AND any(publication IN c.publication WHERE publication IN $publication)"
+	return newConceptContent(results, cntList, cd.thingsURL), queryProfile, nil
+}
+
+// contentForConceptQuery returns the limit pieces of content following the first skipCount, in the order of the sort.
+// The groups of the content and the types of its relationships with the concept are returned too when asked for.
+func contentForConceptQuery(conceptUUID string, params RequestParams, skipCount, limit int, weights RelevanceWeights, results *[]contentResult) *Query {
+	// /content leaves the dates out unless both are after the epoch, as it always has
+	dated := params.FromDateEpoch > 0 && params.ToDateEpoch > 0
+	filter, publication := contentFilter(dated, params.Publication)
+
+	var returned string
+	if params.GroupBy != "" {
+		returned += ", " + groupsExpression(params.GroupBy) + " as groups"
+	}
+	if params.IncludeAnnotations {
+		returned += ", relationships"
 	}
 
 	parameters := map[string]interface{}{
 		"conceptUUID":     conceptUUID,
 		"skipCount":       skipCount,
-		"maxContentItems": params.ContentLimit,
+		"maxContentItems": limit,
 		"fromDate":        params.FromDateEpoch,
 		"toDate":          params.ToDateEpoch,
-		"publication":     params.Publication,
+		"publication":     publication,
+	}
+	if params.Sort == SortRelevance {
+		for name, value := range weights.params(time.Now()) {
+			parameters[name] = value
+		}
 	}
 
 	// New concordance model
-	query := &cmneo4j.Query{
+	return &Query{
 		Cypher: `
 			MATCH (:Concept{uuid:$conceptUUID})-[:EQUIVALENT_TO]->(canon:Concept)
-			MATCH (canon)<-[:EQUIVALENT_TO]-(leaves)<-[]-(c:Content)
+			WITH canon, [(canon)<-[:EQUIVALENT_TO]-(source) | source.uuid] as leafUUIDs
+			MATCH (canon)<-[:EQUIVALENT_TO]-(leaves)<-[rel]-(c:Content)
 			WHERE NOT 'LiveEvent' IN labels(c)` +
-			dateFilter +
-			publicationFilter +
-			` WITH DISTINCT c
-			ORDER BY c.publishedDateEpoch DESC
+			filter +
+			orderBy(params.Sort, "canon, leafUUIDs", params.IncludeAnnotations) + `
 			SKIP ($skipCount)
-			RETURN c.uuid as uuid, labels(c) as types, c.publication as publication
+			RETURN c.uuid as uuid, labels(c) as types, c.publication as publication,
+				canon.prefUUID as canonicalUUID, canon.prefLabel as canonicalPrefLabel, labels(canon) as canonicalTypes, leafUUIDs` +
+			returned + `
 			LIMIT($maxContentItems)`,
 		Params: parameters,
-		Result: &results,
+		Result: results,
+	}
+}
+
+// contentFilter returns the conditions on the published date and publications of content c, along with the publications
+// to filter by, which default to FT Pink. When dated is set, the query is expected to have $fromDate and $toDate
+// parameters, either of which may be the epoch itself. It is always expected to have a $publication parameter.
+func contentFilter(dated bool, publication []string) (string, []string) {
+	var filter string
+	if dated {
+		filter = " AND c.publishedDateEpoch > $fromDate AND c.publishedDateEpoch < $toDate"
+	}
+
+	if len(publication) == 0 {
+		// default to FT Pink if no publication param is supplied
+		publication = []string{ftPinkPublication}
 	}
 
-	err := cd.driver.Read(query)
-	if errors.Is(err, cmneo4j.ErrNoResultsFound) {
-		return nil, ErrContentNotFound
+	if slices.Contains(publication, ftPinkPublication) {
+		// include the old records that do not have publication field when publication filter is supplied
+		filter += " AND (c.publication IS NULL OR any(publication IN c.publication WHERE publication IN $publication))"
+	} else {
+		filter += " AND any(publication IN c.publication WHERE publication IN $publication)"
+	}
+	return filter, publication
+}
+
+func (cd *ConceptService) GetContentForConceptImplicitly(ctx context.Context, conceptUUID string, params ImplicitParams) (ConceptContent, error) {
+	result, _, err := cd.getContentForConceptImplicitly(ctx, conceptUUID, params, false)
+	return result, err
+}
+
+// ProfileContentForConceptImplicitly runs the GetContentForConceptImplicitly query with PROFILE
+// and returns its plan along with the content.
+func (cd *ConceptService) ProfileContentForConceptImplicitly(ctx context.Context, conceptUUID string, params ImplicitParams) (ConceptContent, *QueryProfile, error) {
+	return cd.getContentForConceptImplicitly(ctx, conceptUUID, params, true)
+}
+
+func (cd *ConceptService) getContentForConceptImplicitly(ctx context.Context, conceptUUID string, params ImplicitParams, profile bool) (ConceptContent, *QueryProfile, error) {
+	var results []contentResult
+	query := implicitContentForConceptQuery(conceptUUID, params, &results)
+
+	info := queryInfo{endpoint: endpointImplicitContent, conceptUUID: conceptUUID}
+	queryProfile, err := cd.read(ctx, info, query, profile)
+	if errors.Is(err, errNoResults) {
+		result, err := cd.noContentFound(ctx, conceptUUID)
+		return result, queryProfile, err
 	}
 	if err != nil {
-		return nil, err
+		return ConceptContent{}, queryProfile, err
+	}
+
+	if params.IncludeAnnotations {
+		results = mergeRelationships(results)
 	}
 
 	cntList := make([]Content, 0)
 	for _, result := range results {
-		cntList = append(cntList, Content{
-			ID:          idURL(result.UUID),
-			APIURL:      apiURL(result.UUID, cd.apiURL),
-			Publication: result.Publication,
-		})
+		cnt := Content{
+			ID:     idURL(result.UUID, cd.thingsURL),
+			APIURL: apiURL(result.UUID, cd.apiBaseURL(ctx)),
+		}
+		if params.IncludeAnnotations {
+			cnt.Annotations = annotationPredicates(result.Relationships)
+		}
+		cntList = append(cntList, cnt)
 	}
 
-	return cntList, nil
+	return newConceptContent(results, cntList, cd.thingsURL), queryProfile, nil
 }
 
-func (cd *ConceptService) GetContentForConceptImplicitly(conceptUUID string) ([]Content, error) {
-	var results []struct {
-		UUID  string   `json:"uuid"`
-		Types []string `json:"types"`
+func implicitContentForConceptQuery(conceptUUID string, params ImplicitParams, results *[]contentResult) *Query {
+	var collect, returned string
+	if params.IncludeAnnotations {
+		collect = ", " + relationshipsAggregate
+		returned = ", relationships"
 	}
 
-	query := &cmneo4j.Query{
-		Cypher: ` 
-		MATCH (:Thing{uuid:$conceptUUID})-[:EQUIVALENT_TO]->(canonicalConcept:Concept)
-		MATCH (canonicalConcept)<-[:EQUIVALENT_TO]-(leaf)
-		MATCH (leaf)<-[:HAS_BROADER|HAS_PARENT|IS_PART_OF*0..]-(narrowerLeaf)
-		MATCH (narrowerLeaf)-[:EQUIVALENT_TO]->(narrowerCanonical)
-		WITH DISTINCT narrowerCanonical
-		MATCH (narrowerCanonical)<-[:EQUIVALENT_TO]-(conceptLeaves)
-		MATCH (conceptLeaves)-[]-(content:Content)
-		WITH DISTINCT content
-		RETURN content.uuid as uuid, labels(content) as types
-		UNION
+	var branches []string
+	for _, path := range implicitPaths(params) {
+		branches = append(branches, `
 		MATCH (:Thing{uuid:$conceptUUID})-[:EQUIVALENT_TO]->(canonicalConcept:Concept)
+		WITH canonicalConcept, [(canonicalConcept)<-[:EQUIVALENT_TO]-(source) | source.uuid] as leafUUIDs
 		MATCH (canonicalConcept)<-[:EQUIVALENT_TO]-(leaf)
-		MATCH (leaf)-[:IMPLIED_BY*0..]->(narrowerLeaf)
+		MATCH `+path+`
 		MATCH (narrowerLeaf)-[:EQUIVALENT_TO]->(narrowerCanonical)
-		WITH DISTINCT narrowerCanonical
+		WITH DISTINCT narrowerCanonical, canonicalConcept, leafUUIDs
 		MATCH (narrowerCanonical)<-[:EQUIVALENT_TO]-(conceptLeaves)
-		MATCH (conceptLeaves)-[]-(content:Content)
-		WITH DISTINCT content
-		RETURN content.uuid as uuid, labels(content) as types`,
+		MATCH (conceptLeaves)-[rel]-(content:Content)
+		WITH DISTINCT content, canonicalConcept, leafUUIDs`+collect+`
+		RETURN content.uuid as uuid, labels(content) as types, canonicalConcept.prefUUID as canonicalUUID,
+			canonicalConcept.prefLabel as canonicalPrefLabel, labels(canonicalConcept) as canonicalTypes, leafUUIDs`+returned)
+	}
+
+	return &Query{
+		Cypher: strings.Join(branches, `
+		UNION`),
+		Params: map[string]interface{}{"conceptUUID": conceptUUID},
+		Result: results,
+	}
+}
+
+// noContentFound tells apart a concept without content, reported as ErrContentNotFound along with its canonical concept,
+// from a concept that does not exist, reported as ErrConceptNotFound.
+func (cd *ConceptService) noContentFound(ctx context.Context, conceptUUID string) (ConceptContent, error) {
+	var results []contentResult
+	query := &Query{
+		Cypher: `
+			MATCH (:Concept{uuid:$conceptUUID})-[:EQUIVALENT_TO]->(canon:Concept)
+			RETURN canon.prefUUID as canonicalUUID, canon.prefLabel as canonicalPrefLabel, labels(canon) as canonicalTypes,
+				[(canon)<-[:EQUIVALENT_TO]-(source) | source.uuid] as leafUUIDs`,
 		Params: map[string]interface{}{"conceptUUID": conceptUUID},
 		Result: &results,
 	}
 
-	err := cd.driver.Read(query)
-	if errors.Is(err, cmneo4j.ErrNoResultsFound) {
-		return nil, ErrContentNotFound
+	info := queryInfo{endpoint: endpointConceptExists, conceptUUID: conceptUUID}
+	_, err := cd.read(ctx, info, query, false)
+	if errors.Is(err, errNoResults) {
+		return ConceptContent{}, ErrConceptNotFound
 	}
 	if err != nil {
-		return nil, err
+		return ConceptContent{}, err
 	}
+	return newConceptContent(results, []Content{}, cd.thingsURL), ErrContentNotFound
+}
 
-	cntList := make([]Content, 0)
-	for _, result := range results {
-		cntList = append(cntList, Content{
-			ID:     idURL(result.UUID),
-			APIURL: apiURL(result.UUID, cd.apiURL),
-		})
+// CanonicalUUID returns the UUID of the canonical concept the concept is concorded to, which differs from the UUID
+// of the concept when it was concorded into another one. Concepts that do not exist are reported as ErrConceptNotFound.
+func (cd *ConceptService) CanonicalUUID(ctx context.Context, conceptUUID string) (string, error) {
+	var results []contentResult
+	query := &Query{
+		Cypher: `
+			MATCH (:Concept{uuid:$conceptUUID})-[:EQUIVALENT_TO]->(canon:Concept)
+			RETURN canon.prefUUID as canonicalUUID`,
+		Params: map[string]interface{}{"conceptUUID": conceptUUID},
+		Result: &results,
+	}
+
+	info := queryInfo{endpoint: endpointCanonicalConcept, conceptUUID: conceptUUID}
+	_, err := cd.read(ctx, info, query, false)
+	if errors.Is(err, errNoResults) {
+		return "", ErrConceptNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return results[0].CanonicalUUID, nil
+}
+
+// newConceptContent returns the content along with the canonical concept the results were found through.
+// All rows share the same one.
+func newConceptContent(results []contentResult, cntList []Content, thingsURL string) ConceptContent {
+	if len(results) == 0 {
+		return ConceptContent{Content: cntList}
 	}
 
-	return cntList, nil
+	canon := results[0]
+	leafIDs := make([]string, 0, len(canon.LeafUUIDs))
+	for _, leafUUID := range canon.LeafUUIDs {
+		leafIDs = append(leafIDs, idURL(leafUUID, thingsURL))
+	}
+	slices.Sort(leafIDs)
+
+	return ConceptContent{
+		CanonicalUUID: canon.CanonicalUUID,
+		Concept: &Concept{
+			ID:           idURL(canon.CanonicalUUID, thingsURL),
+			PrefLabel:    canon.CanonicalPrefLabel,
+			Type:         mostSpecificType(canon.CanonicalTypes),
+			ConcordedIDs: leafIDs,
+		},
+		Content: cntList,
+	}
 }
 
-func idURL(uuid string) string {
-	return ThingsPrefix + uuid
+func idURL(uuid, thingsURL string) string {
+	return thingsURL + uuid
 }
 
 func apiURL(uuid, baseURL string) string {
@@ -5,11 +5,13 @@ package content
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -20,6 +22,17 @@ import (
 	cmneo4j "github.com/Financial-Times/cm-neo4j-driver"
 	"github.com/Financial-Times/concepts-rw-neo4j/concepts"
 	"github.com/Financial-Times/go-logger/v2"
+	loggertest "github.com/Financial-Times/go-logger/v2/test"
+	transactionidutils "github.com/Financial-Times/transactionid-utils-go"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -38,6 +51,7 @@ const (
 	content12UUID           = "3fc9fe3e-af8c-4f7f-961a-e5065392bb33"
 	MSJConceptUUID          = "5d1510f8-2779-4b74-adab-0a5eb138fca6"
 	FakebookConceptUUID     = "eac853f5-3859-4c08-8540-55e043719400"
+	FakebookPaymentsUUID    = "7b8c6a52-2f4d-4a8e-9c1f-3e5d7a9b1c20"
 	MetalMickeyConceptUUID  = "0483bef8-5797-40b8-9b25-b12e492f63c6"
 	OnyxPikeBrandUUID       = "9a07c16f-def0-457d-a04a-57ba68ba1e00"
 	OnyxPikeParentBrandUUID = "0635a44c-2e9e-49b6-b078-be53b0e5301b"
@@ -64,8 +78,12 @@ const (
 const defaultLimit = 10
 const defaultPage = 1
 
-// cmneo4j.Driver is safe to use in different go routines, so it's not that problematic that it is used as global var.
-var driver *cmneo4j.Driver
+// Both drivers are safe to use in different go routines, so it's not that problematic that they are used as global vars.
+// driver writes the fixtures through the read-write services, serviceDriver is the one the ConceptService reads with.
+var (
+	driver        *cmneo4j.Driver
+	serviceDriver *Driver
+)
 
 func init() {
 	log := logger.NewUPPLogger("test-service", "info")
@@ -73,6 +91,11 @@ func init() {
 	if driver == nil {
 		log.Fatal("Cannot connect to Neo4J with cmneo4j driver")
 	}
+	var err error
+	serviceDriver, err = NewDriver(neoURL(), neo4j.NoAuth(), "")
+	if err != nil {
+		log.WithError(err).Fatal("Cannot create the neo4j driver")
+	}
 }
 
 func neoURL() string {
@@ -108,12 +131,286 @@ func TestFindMatchingContentForV2Annotation(t *testing.T) {
 
 	defer cleanDB(t, MSJConceptUUID, contentUUID, FakebookConceptUUID)
 
-	contentByConceptDriver, err := NewContentByConceptService(driver, apigURL)
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
 	assert.NoError(err)
-	contentList, err := contentByConceptDriver.GetContentForConcept(MSJConceptUUID, RequestParams{0, defaultLimit, 0, 0, nil})
+	result, err := contentByConceptDriver.GetContentForConcept(context.Background(), MSJConceptUUID, RequestParams{0, defaultLimit, 0, 0, nil, SortNewest, 0, "", false})
+	contentList := result.Content
 	assert.NoError(err, "Unexpected error for concept %s", MSJConceptUUID)
 	assert.Equal(1, len(contentList), "Didn't get the same list of content")
 	assertListContainsAll(assert, contentList, getExpectedContent(contentUUID, nil))
+	assert.Equal(MSJConceptUUID, result.CanonicalUUID, "Didn't resolve the canonical concept")
+	assert.Equal(&Concept{
+		ID:           ThingsPrefix + MSJConceptUUID,
+		PrefLabel:    "The Mall Street Journal",
+		Type:         "Organisation",
+		ConcordedIDs: []string{ThingsPrefix + MSJConceptUUID},
+	}, result.Concept, "Didn't return the canonical concept")
+}
+
+func TestContentURLsAreBuiltFromConfiguredAndRequestBaseURLs(t *testing.T) {
+	assert := assert.New(t)
+
+	writeContent(assert, contentUUID)
+	writeAnnotations(assert, driver, contentUUID, "v2", "./fixtures/Annotations-3fc9fe3e-af8c-4f7f-961a-e5065392bb31-v2.json", nil)
+	writeConcept(assert, driver, "./fixtures/Organisation-MSJ-5d1510f8-2779-4b74-adab-0a5eb138fca6.json")
+
+	defer cleanDB(t, MSJConceptUUID, contentUUID, FakebookConceptUUID)
+
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL, WithFTURL("https://www.ft.com/"))
+	assert.NoError(err)
+	ctx := ContextWithAPIURL(context.Background(), "https://api-t.ft.com")
+	result, err := contentByConceptDriver.GetContentForConcept(ctx, MSJConceptUUID, RequestParams{0, defaultLimit, 0, 0, nil, SortNewest, 0, "", false})
+	assert.NoError(err, "Unexpected error for concept %s", MSJConceptUUID)
+	assert.Equal([]Content{{
+		ID:     "https://www.ft.com/things/" + contentUUID,
+		APIURL: "https://api-t.ft.com/content/" + contentUUID,
+	}}, result.Content)
+	assert.Equal("https://www.ft.com/things/"+MSJConceptUUID, result.Concept.ID)
+}
+
+func TestGetContentForConcepts(t *testing.T) {
+	assert := assert.New(t)
+
+	writeContent(assert, contentUUID)
+	writeAnnotations(assert, driver, contentUUID, "v2", "./fixtures/Annotations-3fc9fe3e-af8c-4f7f-961a-e5065392bb31-v2.json", nil)
+	writeConcept(assert, driver, "./fixtures/Organisation-MSJ-5d1510f8-2779-4b74-adab-0a5eb138fca6.json")
+
+	defer cleanDB(t, MSJConceptUUID, contentUUID, FakebookConceptUUID)
+
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL, WithBatchConcurrency(2))
+	assert.NoError(err)
+	results := contentByConceptDriver.GetContentForConcepts(context.Background(), []ConceptQuery{
+		{ConceptUUID: MSJConceptUUID, Params: RequestParams{0, defaultLimit, 0, 0, nil, SortNewest, 0, "", false}},
+		{ConceptUUID: MetalMickeyConceptUUID, Params: RequestParams{0, defaultLimit, 0, 0, nil, SortNewest, 0, "", false}},
+		{ConceptUUID: MSJConceptUUID, Params: RequestParams{2, defaultLimit, 0, 0, nil, SortNewest, 0, "", false}},
+	})
+
+	assert.Len(results, 3)
+	assert.NoError(results[0].Err, "Unexpected error for concept %s", MSJConceptUUID)
+	assertListContainsAll(assert, results[0].Content, getExpectedContent(contentUUID, nil))
+	assert.Equal(ErrConceptNotFound, results[1].Err)
+	assert.Equal(ErrContentNotFound, results[2].Err)
+}
+
+func TestCountContentForConcepts(t *testing.T) {
+	assert := assert.New(t)
+
+	writeContent(assert, contentUUID)
+	writeAnnotations(assert, driver, contentUUID, "v2", "./fixtures/Annotations-3fc9fe3e-af8c-4f7f-961a-e5065392bb31-v2.json", nil)
+	writeConcept(assert, driver, "./fixtures/Organisation-MSJ-5d1510f8-2779-4b74-adab-0a5eb138fca6.json")
+
+	defer cleanDB(t, MSJConceptUUID, contentUUID, FakebookConceptUUID)
+
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
+	assert.NoError(err)
+
+	tests := []struct {
+		name     string
+		params   CountParams
+		expected map[string]int
+	}{
+		{"no filters", CountParams{}, map[string]int{MSJConceptUUID: 1}},
+		{"matching predicate", CountParams{Predicates: []string{"about"}}, map[string]int{MSJConceptUUID: 1}},
+		{"other predicate", CountParams{Predicates: []string{"mentions"}}, map[string]int{MSJConceptUUID: 0}},
+		{"other content type", CountParams{ContentTypes: []string{"LiveBlogPost"}}, map[string]int{MSJConceptUUID: 0}},
+		{"other publication", CountParams{Publication: []string{svPublicationID}}, map[string]int{MSJConceptUUID: 0}},
+		{"implicitly", CountParams{Implicit: true}, map[string]int{MSJConceptUUID: 1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			counts, err := contentByConceptDriver.CountContentForConcepts(context.Background(), []string{MSJConceptUUID, MetalMickeyConceptUUID}, test.params)
+			assert.NoError(err)
+			assert.Equal(test.expected, counts)
+		})
+	}
+
+	_, err = contentByConceptDriver.CountContentForConcepts(context.Background(), []string{MSJConceptUUID}, CountParams{Predicates: []string{"likes"}})
+	assert.ErrorIs(err, ErrUnknownPredicate)
+}
+
+func TestCountContentForConceptsImplicitlyMatchesImplicitContent(t *testing.T) {
+	assert := assert.New(t)
+
+	defer cleanDB(t, content5UUID, content6UUID, topic1UUID, topic2UUID)
+
+	writeContent(assert, content5UUID)
+	writeContent(assert, content6UUID)
+
+	writeAnnotations(assert, driver, content5UUID, "v2", "./fixtures/Annotations-8a08dfe3-88c4-47dd-bee6-846ede810448-V2.json", nil)
+	writeAnnotations(assert, driver, content6UUID, "v2", "./fixtures/Annotations-27c47a08-6bad-486d-8e06-ce24d583ae2a-V2.json", []interface{}{svPublicationID})
+
+	writeConcept(assert, driver, "./fixtures/Topic-18e24d65-c8e6-4e23-ab19-206e0d463205.json")
+	writeConcept(assert, driver, "./fixtures/Topic-64ba2208-0c0d-43e2-a883-beecb55c0d33.json")
+
+	// the implicit content is not filtered by publication or content type, unlike /content
+	err := driver.Write(&cmneo4j.Query{
+		Cypher: `MATCH (c:Content{uuid:$uuid}) SET c:LiveEvent`,
+		Params: map[string]interface{}{"uuid": content5UUID},
+	})
+	assert.NoError(err)
+
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
+	assert.NoError(err)
+
+	implicit, err := contentByConceptDriver.GetContentForConceptImplicitly(context.Background(), topic2UUID, ImplicitParams{})
+	assert.NoError(err)
+	assert.Len(implicit.Content, 2)
+
+	counts, err := contentByConceptDriver.CountContentForConcepts(context.Background(), []string{topic2UUID}, CountParams{Implicit: true})
+	assert.NoError(err)
+	assert.Equal(map[string]int{topic2UUID: len(implicit.Content)}, counts)
+
+	counts, err = contentByConceptDriver.CountContentForConcepts(context.Background(), []string{topic2UUID}, CountParams{Implicit: true, Publication: []string{svPublicationID}})
+	assert.NoError(err)
+	assert.Equal(map[string]int{topic2UUID: 1}, counts)
+}
+
+func TestGetContentHistogram(t *testing.T) {
+	assert := assert.New(t)
+
+	writeContent(assert, contentUUID)
+	writeAnnotations(assert, driver, contentUUID, "v2", "./fixtures/Annotations-3fc9fe3e-af8c-4f7f-961a-e5065392bb31-v2.json", nil)
+	writeConcept(assert, driver, "./fixtures/Organisation-MSJ-5d1510f8-2779-4b74-adab-0a5eb138fca6.json")
+
+	defer cleanDB(t, MSJConceptUUID, contentUUID, FakebookConceptUUID)
+
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
+	assert.NoError(err)
+
+	epoch := func(date string) int64 {
+		d, _ := time.Parse("2006-01-02", date)
+		return d.Unix()
+	}
+
+	weeks, err := contentByConceptDriver.GetContentHistogram(context.Background(), MSJConceptUUID, HistogramParams{
+		Interval:      "week",
+		FromDateEpoch: epoch("2014-03-01"),
+		ToDateEpoch:   epoch("2014-03-20"),
+	})
+	assert.NoError(err)
+	assert.Equal([]HistogramBucket{{"2014-02-24", 0}, {"2014-03-03", 1}, {"2014-03-10", 0}, {"2014-03-17", 0}}, weeks)
+
+	months, err := contentByConceptDriver.GetContentHistogram(context.Background(), MSJConceptUUID, HistogramParams{
+		Interval:      "month",
+		FromDateEpoch: epoch("2014-01-15"),
+		ToDateEpoch:   epoch("2014-04-01"),
+		Publication:   []string{svPublicationID},
+	})
+	assert.NoError(err)
+	assert.Equal([]HistogramBucket{{"2014-01-01", 0}, {"2014-02-01", 0}, {"2014-03-01", 0}}, months)
+
+	// the epoch is a date like any other, the content published after toDate is still left out
+	sinceEpoch, err := contentByConceptDriver.GetContentHistogram(context.Background(), MSJConceptUUID, HistogramParams{
+		Interval:      "month",
+		FromDateEpoch: epoch("1970-01-01"),
+		ToDateEpoch:   epoch("2014-03-01"),
+	})
+	assert.NoError(err)
+	if assert.NotEmpty(sinceEpoch) {
+		assert.Equal(HistogramBucket{"1970-01-01", 0}, sinceEpoch[0])
+		assert.Equal(HistogramBucket{"2014-03-01", 0}, sinceEpoch[len(sinceEpoch)-1])
+	}
+
+	_, err = contentByConceptDriver.GetContentHistogram(context.Background(), MetalMickeyConceptUUID, HistogramParams{
+		Interval:      "day",
+		FromDateEpoch: epoch("2014-03-01"),
+		ToDateEpoch:   epoch("2014-03-20"),
+	})
+	assert.Equal(ErrConceptNotFound, err)
+
+	_, err = contentByConceptDriver.GetContentHistogram(context.Background(), MSJConceptUUID, HistogramParams{
+		Interval:      "day",
+		FromDateEpoch: epoch("2000-01-01"),
+		ToDateEpoch:   epoch("2014-03-20"),
+	})
+	assert.Equal(ErrTooManyBuckets, err)
+}
+
+func TestGetTrendingConcepts(t *testing.T) {
+	assert := assert.New(t)
+
+	writeContent(assert, contentUUID)
+	writeAnnotations(assert, driver, contentUUID, "v2", "./fixtures/Annotations-3fc9fe3e-af8c-4f7f-961a-e5065392bb31-v2.json", nil)
+	writeConcept(assert, driver, "./fixtures/Organisation-MSJ-5d1510f8-2779-4b74-adab-0a5eb138fca6.json")
+
+	defer cleanDB(t, MSJConceptUUID, contentUUID, FakebookConceptUUID)
+
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
+	assert.NoError(err)
+
+	params := TrendingParams{
+		Window:   48 * time.Hour,
+		Baseline: 7 * 24 * time.Hour,
+		Until:    time.Date(2014, 3, 8, 0, 0, 0, 0, time.UTC),
+		MinCount: 1,
+		Limit:    10,
+	}
+	concepts, err := contentByConceptDriver.GetTrendingConcepts(context.Background(), params)
+	assert.NoError(err)
+	assert.Contains(concepts, TrendingConcept{
+		ID:          ThingsPrefix + MSJConceptUUID,
+		PrefLabel:   "The Mall Street Journal",
+		Type:        "Organisation",
+		RecentCount: 1,
+		Growth:      3.5,
+	})
+
+	params.ConceptTypes = []string{"Person"}
+	concepts, err = contentByConceptDriver.GetTrendingConcepts(context.Background(), params)
+	assert.NoError(err)
+	assert.Empty(concepts)
+
+	params.ConceptTypes = nil
+	params.Until = time.Date(2014, 3, 20, 0, 0, 0, 0, time.UTC)
+	concepts, err = contentByConceptDriver.GetTrendingConcepts(context.Background(), params)
+	assert.NoError(err)
+	assert.Empty(concepts, "content in the baseline window only should not trend")
+}
+
+func TestGetCooccurringConcepts(t *testing.T) {
+	assert := assert.New(t)
+
+	writeContent(assert, contentUUID)
+	writeAnnotations(assert, driver, contentUUID, "v1", "./fixtures/Annotations-3fc9fe3e-af8c-4f7f-961a-e5065392bb31-v1.json", nil)
+	writeAnnotations(assert, driver, contentUUID, "v2", "./fixtures/Annotations-3fc9fe3e-af8c-4f7f-961a-e5065392bb31-v2.json", nil)
+	writeConcept(assert, driver, "./fixtures/Subject-MetalMickey-0483bef8-5797-40b8-9b25-b12e492f63c6.json")
+	writeConcept(assert, driver, "./fixtures/Organisation-MSJ-5d1510f8-2779-4b74-adab-0a5eb138fca6.json")
+
+	defer cleanDB(t, MSJConceptUUID, contentUUID, FakebookConceptUUID, MetalMickeyConceptUUID)
+
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
+	assert.NoError(err)
+
+	metalMickey := CooccurringConcept{
+		ID:         ThingsPrefix + MetalMickeyConceptUUID,
+		PrefLabel:  "Metal Mickey",
+		Type:       "Subject",
+		Count:      1,
+		Similarity: 1,
+	}
+
+	concepts, err := contentByConceptDriver.GetCooccurringConcepts(context.Background(), MSJConceptUUID, CooccurrenceParams{Limit: 10})
+	assert.NoError(err)
+	assert.Equal([]CooccurringConcept{metalMickey}, concepts)
+
+	concepts, err = contentByConceptDriver.GetCooccurringConcepts(context.Background(), MSJConceptUUID, CooccurrenceParams{
+		ConceptTypes: []string{"Subject"},
+		Predicates:   []string{"about", "isClassifiedBy"},
+		Limit:        10,
+	})
+	assert.NoError(err)
+	assert.Equal([]CooccurringConcept{metalMickey}, concepts)
+
+	concepts, err = contentByConceptDriver.GetCooccurringConcepts(context.Background(), MSJConceptUUID, CooccurrenceParams{Predicates: []string{"about"}, Limit: 10})
+	assert.NoError(err)
+	assert.Empty(concepts)
+
+	concepts, err = contentByConceptDriver.GetCooccurringConcepts(context.Background(), MSJConceptUUID, CooccurrenceParams{ConceptTypes: []string{"Person"}, Limit: 10})
+	assert.NoError(err)
+	assert.Empty(concepts)
+
+	_, err = contentByConceptDriver.GetCooccurringConcepts(context.Background(), FakebookConceptUUID, CooccurrenceParams{Limit: 10})
+	assert.Equal(ErrConceptNotFound, err)
 }
 
 func TestFindMatchingContentForV1Annotation(t *testing.T) {
@@ -126,9 +423,10 @@ func TestFindMatchingContentForV1Annotation(t *testing.T) {
 
 	defer cleanDB(t, MSJConceptUUID, contentUUID, FakebookConceptUUID, MetalMickeyConceptUUID)
 
-	contentByConceptDriver, err := NewContentByConceptService(driver, apigURL)
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
 	assert.NoError(err)
-	contentList, err := contentByConceptDriver.GetContentForConcept(MetalMickeyConceptUUID, RequestParams{0, defaultLimit, 0, 0, nil})
+	result, err := contentByConceptDriver.GetContentForConcept(context.Background(), MetalMickeyConceptUUID, RequestParams{0, defaultLimit, 0, 0, nil, SortNewest, 0, "", false})
+	contentList := result.Content
 	assert.NoError(err, "Unexpected error for concept %s", MetalMickeyConceptUUID)
 	assert.Equal(1, len(contentList), "Didn't get the same list of content")
 	assertListContainsAll(assert, contentList, getExpectedContent(contentUUID, nil))
@@ -144,9 +442,10 @@ func TestFindMatchingContentForV2AnnotationWithLimit(t *testing.T) {
 
 	defer cleanDB(t, MSJConceptUUID, contentUUID, FakebookConceptUUID, content2UUID)
 
-	contentByConceptDriver, err := NewContentByConceptService(driver, apigURL)
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
 	assert.NoError(err)
-	contentList, err := contentByConceptDriver.GetContentForConcept(MSJConceptUUID, RequestParams{0, 1, 0, 0, nil})
+	result, err := contentByConceptDriver.GetContentForConcept(context.Background(), MSJConceptUUID, RequestParams{0, 1, 0, 0, nil, SortNewest, 0, "", false})
+	contentList := result.Content
 	assert.NoError(err, "Unexpected error for concept %s", MSJConceptUUID)
 	assert.Equal(1, len(contentList), "Didn't get the same list of content")
 	assertListContainsAll(assert, contentList, getExpectedContent(contentUUID, nil))
@@ -161,11 +460,12 @@ func TestRetrieveNoContentForV1AnnotationForExclusiveDatePeriod(t *testing.T) {
 
 	defer cleanDB(t, MSJConceptUUID, contentUUID, FakebookConceptUUID, MetalMickeyConceptUUID)
 
-	contentByConceptDriver, err := NewContentByConceptService(driver, apigURL)
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
 	assert.NoError(err)
 	fromDate, _ := time.Parse("2006-01-02", "2014-03-08")
 	toDate, _ := time.Parse("2006-01-02", "2014-03-09")
-	contentList, err := contentByConceptDriver.GetContentForConcept(MetalMickeyConceptUUID, RequestParams{0, defaultLimit, fromDate.Unix(), toDate.Unix(), nil})
+	result, err := contentByConceptDriver.GetContentForConcept(context.Background(), MetalMickeyConceptUUID, RequestParams{0, defaultLimit, fromDate.Unix(), toDate.Unix(), nil, SortNewest, 0, "", false})
+	contentList := result.Content
 	assert.Equal(ErrContentNotFound, err, "Found matching content for concept %s", MetalMickeyConceptUUID)
 	assert.Equal(0, len(contentList), "Should not get any content items")
 }
@@ -177,10 +477,11 @@ func TestRetrieveNoContentWhenThereAreNoContentForThatConcept(t *testing.T) {
 
 	defer cleanDB(t, MSJConceptUUID, contentUUID, FakebookConceptUUID)
 
-	contentByConceptDriver, err := NewContentByConceptService(driver, apigURL)
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
 	assert.NoError(err)
-	content, err := contentByConceptDriver.GetContentForConcept(MSJConceptUUID, RequestParams{0, defaultLimit, 0, 0, nil})
-	assert.Equal(ErrContentNotFound, err, "Found matching content for concept %s", MetalMickeyConceptUUID)
+	result, err := contentByConceptDriver.GetContentForConcept(context.Background(), MSJConceptUUID, RequestParams{0, defaultLimit, 0, 0, nil, SortNewest, 0, "", false})
+	content := result.Content
+	assert.Equal(ErrConceptNotFound, err, "Found matching content for concept %s", MetalMickeyConceptUUID)
 	assert.Equal(0, len(content), "Should not get any content items")
 }
 
@@ -193,10 +494,11 @@ func TestRetrieveNoContentWhenThereAreNoConceptsPresent(t *testing.T) {
 
 	defer cleanDB(t, content2UUID, MSJConceptUUID, contentUUID, MetalMickeyConceptUUID, FakebookConceptUUID)
 
-	contentByConceptDriver, err := NewContentByConceptService(driver, apigURL)
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
 	assert.NoError(err)
-	contentList, err := contentByConceptDriver.GetContentForConcept(MSJConceptUUID, RequestParams{0, defaultLimit, 0, 0, nil})
-	assert.Equal(ErrContentNotFound, err, "Found matching content for concept %s", MetalMickeyConceptUUID)
+	result, err := contentByConceptDriver.GetContentForConcept(context.Background(), MSJConceptUUID, RequestParams{0, defaultLimit, 0, 0, nil, SortNewest, 0, "", false})
+	contentList := result.Content
+	assert.Equal(ErrConceptNotFound, err, "Found matching content for concept %s", MetalMickeyConceptUUID)
 	assert.Equal(0, len(contentList), "Didn't get the right number of content items, content=%s", contentList)
 }
 
@@ -215,13 +517,138 @@ func TestBrandsDontReturnParentContent(t *testing.T) {
 	writeConcept(assert, driver, fmt.Sprintf("./fixtures/Brand-OnyxPike-%v.json", OnyxPikeBrandUUID))
 	writeConcept(assert, driver, fmt.Sprintf("./fixtures/Brand-OnyxPikeParent-%v.json", OnyxPikeParentBrandUUID))
 
-	contentByConceptDriver, err := NewContentByConceptService(driver, apigURL)
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
 	assert.NoError(err)
-	contentList, err := contentByConceptDriver.GetContentForConcept(OnyxPikeBrandUUID, RequestParams{0, defaultLimit, 0, 0, nil})
+	result, err := contentByConceptDriver.GetContentForConcept(context.Background(), OnyxPikeBrandUUID, RequestParams{0, defaultLimit, 0, 0, nil, SortNewest, 0, "", false})
+	contentList := result.Content
 	assert.NoError(err, "Unexpected error for concept %s", OnyxPikeBrandUUID)
 	assert.Equal(2, len(contentList), "Didn't get the right number of content items, content=%s", contentList)
 }
 
+func TestGetContentForConceptSorted(t *testing.T) {
+	assert := assert.New(t)
+	defer cleanDB(t, content2UUID, content3UUID, MSJConceptUUID)
+
+	writeContent(assert, content2UUID)
+	writeContent(assert, content3UUID)
+
+	writeAnnotations(assert, driver, content2UUID, "v2", "./fixtures/Annotations-bfa97890-76ff-4a35-a775-b8768f7ea383-MSJ-about.json", nil)
+	writeAnnotations(assert, driver, content3UUID, "v2", "./fixtures/Annotations-5a9c7429-e76b-4f37-b5d1-842d64a45167-MSJ-mentions.json", nil)
+	writeConcept(assert, driver, "./fixtures/Organisation-MSJ-5d1510f8-2779-4b74-adab-0a5eb138fca6.json")
+
+	// content3 mentions the concept and was published six months after content2, which is about it.
+	recencyFirst, err := NewContentByConceptService(serviceDriver, apigURL)
+	assert.NoError(err)
+	predicatesFirst, err := NewContentByConceptService(serviceDriver, apigURL, WithRelevanceWeights(RelevanceWeights{
+		Predicates: DefaultRelevanceWeights.Predicates,
+		Leaves:     1,
+		HalfLife:   100 * 365 * 24 * time.Hour,
+	}))
+	assert.NoError(err)
+
+	tests := []struct {
+		service  *ConceptService
+		sort     Sort
+		expected []string
+	}{
+		{service: recencyFirst, sort: SortNewest, expected: []string{content3UUID, content2UUID}},
+		{service: recencyFirst, sort: SortOldest, expected: []string{content2UUID, content3UUID}},
+		{service: recencyFirst, sort: SortRelevance, expected: []string{content3UUID, content2UUID}},
+		{service: predicatesFirst, sort: SortRelevance, expected: []string{content2UUID, content3UUID}},
+	}
+	for _, test := range tests {
+		result, err := test.service.GetContentForConcept(context.Background(), MSJConceptUUID, RequestParams{0, defaultLimit, 0, 0, nil, test.sort, 0, "", false})
+		assert.NoError(err, "Unexpected error sorting by %s", test.sort)
+
+		var uuids []string
+		for _, c := range result.Content {
+			uuids = append(uuids, strings.TrimPrefix(c.ID, ThingsPrefix))
+		}
+		assert.Equal(test.expected, uuids, "Wrong order sorting by %s", test.sort)
+	}
+}
+
+func TestGetContentForConceptGrouped(t *testing.T) {
+	assert := assert.New(t)
+	defer cleanDB(t, contentUUID, content3UUID, content11UUID, MSJConceptUUID, OnyxPikeBrandUUID, OnyxPikeParentBrandUUID, OnyPikeyRightBrandUUID)
+
+	writeContent(assert, contentUUID)
+	writeContent(assert, content3UUID)
+	writeContent(assert, content11UUID)
+
+	// content11 and contentUUID are the most recent and both classified by Onyx Pike, while content3 has no brand.
+	writeAnnotations(assert, driver, content11UUID, "v2", "./fixtures/Annotations-22e528d3-4ceb-452f-bf88-0ff6b99eab22-MSJ-OnyxPike.json", nil)
+	writeAnnotations(assert, driver, contentUUID, "v2", "./fixtures/Annotations-3fc9fe3e-af8c-4f7f-961a-e5065392bb31-MSJ-OnyxPike.json", nil)
+	writeAnnotations(assert, driver, content3UUID, "v2", "./fixtures/Annotations-5a9c7429-e76b-4f37-b5d1-842d64a45167-MSJ-mentions.json", nil)
+	writeConcept(assert, driver, "./fixtures/Organisation-MSJ-5d1510f8-2779-4b74-adab-0a5eb138fca6.json")
+	writeConcept(assert, driver, fmt.Sprintf("./fixtures/Brand-OnyxPike-%v.json", OnyxPikeBrandUUID))
+	writeConcept(assert, driver, fmt.Sprintf("./fixtures/Brand-OnyxPikeParent-%v.json", OnyxPikeParentBrandUUID))
+
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
+	assert.NoError(err)
+
+	tests := []struct {
+		params   RequestParams
+		expected []string
+	}{
+		{params: RequestParams{1, 2, 0, 0, nil, SortNewest, 0, "", false}, expected: []string{content11UUID, contentUUID}},
+		{params: RequestParams{1, 2, 0, 0, nil, SortNewest, 1, GroupByBrand, false}, expected: []string{content11UUID, content3UUID}},
+		{params: RequestParams{2, 2, 0, 0, nil, SortNewest, 1, GroupByBrand, false}, expected: []string{contentUUID}},
+		{params: RequestParams{1, 2, 0, 0, nil, SortNewest, 2, GroupByBrand, false}, expected: []string{content11UUID, contentUUID}},
+	}
+	for _, test := range tests {
+		result, err := contentByConceptDriver.GetContentForConcept(context.Background(), MSJConceptUUID, test.params)
+		assert.NoError(err, "Unexpected error for %+v", test.params)
+
+		var uuids []string
+		for _, c := range result.Content {
+			uuids = append(uuids, strings.TrimPrefix(c.ID, ThingsPrefix))
+		}
+		assert.Equal(test.expected, uuids, "Wrong content for %+v", test.params)
+	}
+
+	_, err = contentByConceptDriver.GetContentForConcept(context.Background(), MSJConceptUUID, RequestParams{3, 2, 0, 0, nil, SortNewest, 1, GroupByBrand, false})
+	assert.Equal(ErrContentNotFound, err)
+}
+
+func TestGetContentForConceptWithAnnotations(t *testing.T) {
+	assert := assert.New(t)
+	defer cleanDB(t, content2UUID, content3UUID, MSJConceptUUID)
+
+	writeContent(assert, content2UUID)
+	writeContent(assert, content3UUID)
+
+	writeAnnotations(assert, driver, content2UUID, "v2", "./fixtures/Annotations-bfa97890-76ff-4a35-a775-b8768f7ea383-MSJ-about.json", nil)
+	writeAnnotations(assert, driver, content3UUID, "v2", "./fixtures/Annotations-5a9c7429-e76b-4f37-b5d1-842d64a45167-MSJ-mentions.json", nil)
+	writeConcept(assert, driver, "./fixtures/Organisation-MSJ-5d1510f8-2779-4b74-adab-0a5eb138fca6.json")
+
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
+	assert.NoError(err)
+
+	expected := map[string][]string{content2UUID: {"about"}, content3UUID: {"mentions"}}
+	for _, sort := range Sorts() {
+		result, err := contentByConceptDriver.GetContentForConcept(context.Background(), MSJConceptUUID, RequestParams{0, defaultLimit, 0, 0, nil, sort, 0, "", true})
+		assert.NoError(err, "Unexpected error sorting by %s", sort)
+		assert.Len(result.Content, 2)
+		for _, c := range result.Content {
+			assert.Equal(expected[strings.TrimPrefix(c.ID, ThingsPrefix)], c.Annotations, "Wrong annotations sorting by %s", sort)
+		}
+	}
+
+	implicit, err := contentByConceptDriver.GetContentForConceptImplicitly(context.Background(), MSJConceptUUID, ImplicitParams{IncludeAnnotations: true})
+	assert.NoError(err)
+	assert.Len(implicit.Content, 2)
+	for _, c := range implicit.Content {
+		assert.Equal(expected[strings.TrimPrefix(c.ID, ThingsPrefix)], c.Annotations)
+	}
+
+	result, err := contentByConceptDriver.GetContentForConcept(context.Background(), MSJConceptUUID, RequestParams{0, defaultLimit, 0, 0, nil, SortNewest, 0, "", false})
+	assert.NoError(err)
+	for _, c := range result.Content {
+		assert.Nil(c.Annotations, "Annotations should only be returned when asked for")
+	}
+}
+
 func TestContentIsReturnedFromAllLeafNodesOfConcordance(t *testing.T) {
 	assert := assert.New(t)
 
@@ -239,16 +666,90 @@ func TestContentIsReturnedFromAllLeafNodesOfConcordance(t *testing.T) {
 
 	writeConcept(assert, driver, "./fixtures/Person-JohnSmith-f25b0f71-4cf9-4e3a-8510-14e86d922bfe.json")
 
-	contentByConceptDriver, err := NewContentByConceptService(driver, apigURL)
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
 	assert.NoError(err)
 
 	idsToCheck := []string{JohnSmithFSUUID, JohnSmithSmartlogicUUID, JohnSmithTMEUUID, JohnSmithOtherTMEUUID}
 
 	for _, uuid := range idsToCheck {
-		contentList, err := contentByConceptDriver.GetContentForConcept(uuid, RequestParams{0, defaultLimit, 0, 0, nil})
+		result, err := contentByConceptDriver.GetContentForConcept(context.Background(), uuid, RequestParams{0, defaultLimit, 0, 0, nil, SortNewest, 0, "", false})
+		contentList := result.Content
 		assert.NoError(err, "Unexpected error for concept %s", uuid)
 		assert.Equal(4, len(contentList), "Didn't get the right number of content items, content=%s", contentList)
+		assert.Equal(JohnSmithSmartlogicUUID, result.CanonicalUUID, "Didn't resolve concept %s to its replacement", uuid)
+	}
+}
+
+func TestConceptUUIDForIdentifier(t *testing.T) {
+	assert := assert.New(t)
+
+	defer cleanDB(t, JohnSmithFSUUID, JohnSmithSmartlogicUUID, JohnSmithTMEUUID, JohnSmithOtherTMEUUID)
+
+	writeConcept(assert, driver, "./fixtures/Person-JohnSmith-f25b0f71-4cf9-4e3a-8510-14e86d922bfe.json")
+
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
+	assert.NoError(err)
+
+	identifiers := map[string]string{"TME": "N11dGE8juUH-T04=", "tme": "MwNGJhM2Vi-T04=", "FACTSET": "0ABCD-E", "Smartlogic": JohnSmithSmartlogicUUID}
+	for authority, identifier := range identifiers {
+		conceptUUID, err := contentByConceptDriver.ConceptUUIDForIdentifier(context.Background(), authority, identifier)
+		assert.NoError(err, "Unexpected error for identifier %s:%s", authority, identifier)
+		assert.Equal(JohnSmithSmartlogicUUID, conceptUUID, "Didn't resolve identifier %s:%s", authority, identifier)
+	}
+
+	_, err = contentByConceptDriver.ConceptUUIDForIdentifier(context.Background(), "TME", "unknown")
+	assert.Equal(ErrConceptNotFound, err)
+
+	_, err = contentByConceptDriver.ConceptUUIDForIdentifier(context.Background(), "DBPedia", "John_Smith")
+	assert.Equal(ErrUnknownAuthority, err)
+}
+
+func TestConceptUUIDForAmbiguousIdentifier(t *testing.T) {
+	assert := assert.New(t)
+
+	defer cleanDB(t, JohnSmithFSUUID, JohnSmithSmartlogicUUID, JohnSmithTMEUUID, JohnSmithOtherTMEUUID, MSJConceptUUID)
+
+	writeConcept(assert, driver, "./fixtures/Person-JohnSmith-f25b0f71-4cf9-4e3a-8510-14e86d922bfe.json")
+	writeConcept(assert, driver, "./fixtures/Organisation-MSJ-5d1510f8-2779-4b74-adab-0a5eb138fca6.json")
+
+	// the TME identifier of John Smith identifies another concept as well
+	err := driver.Write(&cmneo4j.Query{
+		Cypher: `
+			MATCH (identifier:TMEIdentifier{value:$identifier}), (other:Concept{uuid:$otherUUID})
+			MERGE (identifier)-[:IDENTIFIES]->(other)`,
+		Params: map[string]interface{}{"identifier": "N11dGE8juUH-T04=", "otherUUID": MSJConceptUUID},
+	})
+	assert.NoError(err)
+
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
+	assert.NoError(err)
+
+	_, err = contentByConceptDriver.ConceptUUIDForIdentifier(context.Background(), "TME", "N11dGE8juUH-T04=")
+	assert.ErrorIs(err, ErrAmbiguousIdentifier)
+
+	conceptUUID, err := contentByConceptDriver.ConceptUUIDForIdentifier(context.Background(), "FACTSET", "0ABCD-E")
+	assert.NoError(err)
+	assert.Equal(JohnSmithSmartlogicUUID, conceptUUID)
+}
+
+func TestCanonicalUUID(t *testing.T) {
+	assert := assert.New(t)
+
+	defer cleanDB(t, JohnSmithFSUUID, JohnSmithSmartlogicUUID, JohnSmithTMEUUID, JohnSmithOtherTMEUUID)
+
+	writeConcept(assert, driver, "./fixtures/Person-JohnSmith-f25b0f71-4cf9-4e3a-8510-14e86d922bfe.json")
+
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
+	assert.NoError(err)
+
+	for _, conceptUUID := range []string{JohnSmithSmartlogicUUID, JohnSmithTMEUUID, JohnSmithFSUUID} {
+		canonicalUUID, err := contentByConceptDriver.CanonicalUUID(context.Background(), conceptUUID)
+		assert.NoError(err, "Unexpected error for concept %s", conceptUUID)
+		assert.Equal(JohnSmithSmartlogicUUID, canonicalUUID, "Didn't resolve concept %s", conceptUUID)
 	}
+
+	_, err = contentByConceptDriver.CanonicalUUID(context.Background(), "00000000-0000-0000-0000-000000000000")
+	assert.Equal(ErrConceptNotFound, err)
 }
 
 func TestContentIsReturnedFromAllLeafNodesOfConcordanceWithDateRestrictions(t *testing.T) {
@@ -268,13 +769,14 @@ func TestContentIsReturnedFromAllLeafNodesOfConcordanceWithDateRestrictions(t *t
 
 	writeConcept(assert, driver, "./fixtures/Person-JohnSmith-f25b0f71-4cf9-4e3a-8510-14e86d922bfe.json")
 
-	contentByConceptDriver, err := NewContentByConceptService(driver, apigURL)
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
 	assert.NoError(err)
 
 	idsToCheck := []string{JohnSmithFSUUID, JohnSmithSmartlogicUUID, JohnSmithTMEUUID, JohnSmithOtherTMEUUID}
 
 	for _, uuid := range idsToCheck {
-		contentList, err := contentByConceptDriver.GetContentForConcept(uuid, RequestParams{0, defaultLimit, 1372550400, 1388448000, nil})
+		result, err := contentByConceptDriver.GetContentForConcept(context.Background(), uuid, RequestParams{0, defaultLimit, 1372550400, 1388448000, nil, SortNewest, 0, "", false})
+		contentList := result.Content
 		//From July 1st 2013 - January 1st 2014
 		assert.NoError(err, "Unexpected error for concept %s", uuid)
 		assert.Equal(1, len(contentList), "Didn't get the right number of content items, content=%s", contentList)
@@ -298,7 +800,7 @@ func TestContentIsReturnedFromAllLeafNodesOfConcordanceWithPagination(t *testing
 
 	writeConcept(assert, driver, "./fixtures/Person-JohnSmith-f25b0f71-4cf9-4e3a-8510-14e86d922bfe.json")
 
-	contentByConceptDriver, err := NewContentByConceptService(driver, apigURL)
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
 	assert.NoError(err)
 
 	idsToCheck := []string{JohnSmithFSUUID, JohnSmithSmartlogicUUID, JohnSmithTMEUUID, JohnSmithOtherTMEUUID}
@@ -313,7 +815,8 @@ func TestContentIsReturnedFromAllLeafNodesOfConcordanceWithPagination(t *testing
 				ContentLimit: pageSize,
 			}
 
-			pageContents, err := contentByConceptDriver.GetContentForConcept(uuid, requestParams)
+			result, err := contentByConceptDriver.GetContentForConcept(context.Background(), uuid, requestParams)
+			pageContents := result.Content
 			if err == ErrContentNotFound {
 				break
 			}
@@ -343,18 +846,21 @@ func TestContentIsReturnedImplicitlyForHasBroaderOrHasParentOrIsPartOfRelationsh
 	writeConcept(assert, driver, "./fixtures/Topic-18e24d65-c8e6-4e23-ab19-206e0d463205.json")
 	writeConcept(assert, driver, "./fixtures/Topic-64ba2208-0c0d-43e2-a883-beecb55c0d33.json")
 
-	contentByConceptDriver, err := NewContentByConceptService(driver, apigURL)
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
 	assert.NoError(err)
 
-	contentList1, err := contentByConceptDriver.GetContentForConcept(topic1UUID, RequestParams{0, defaultLimit, 0, 0, nil})
+	result1, err := contentByConceptDriver.GetContentForConcept(context.Background(), topic1UUID, RequestParams{0, defaultLimit, 0, 0, nil, SortNewest, 0, "", false})
+	contentList1 := result1.Content
 	assert.NoError(err, "Unexpected error for concept %s", topic1UUID)
 	assert.Equal(1, len(contentList1), "Didn't get the right number of content items, content=%s", contentList1)
 
-	contentList2, err := contentByConceptDriver.GetContentForConcept(topic2UUID, RequestParams{0, defaultLimit, 0, 0, nil})
+	result2, err := contentByConceptDriver.GetContentForConcept(context.Background(), topic2UUID, RequestParams{0, defaultLimit, 0, 0, nil, SortNewest, 0, "", false})
+	contentList2 := result2.Content
 	assert.NoError(err, "Unexpected error for concept %s", topic2UUID)
 	assert.Equal(1, len(contentList2), "Didn't get the right number of content items, content=%s", contentList2)
 
-	contentList3, err := contentByConceptDriver.GetContentForConceptImplicitly(topic2UUID)
+	result3, err := contentByConceptDriver.GetContentForConceptImplicitly(context.Background(), topic2UUID, ImplicitParams{})
+	contentList3 := result3.Content
 	assert.NoError(err, "Unexpected error for concept %s", topic2UUID)
 	assert.Equal(2, len(contentList3), "Didn't get the right number of content items, content=%s", contentList3)
 }
@@ -373,30 +879,254 @@ func TestContentIsReturnedImplicitlyForImpliedByRelationship(t *testing.T) {
 	writeConcept(assert, driver, "./fixtures/Brand-5c7592a8-1f0c-11e4-b0cb-b2227cce2b54.json")
 	writeConcept(assert, driver, "./fixtures/Topic-2e7429bd-7a84-41cb-a619-2c702893e359.json")
 
-	contentByConceptDriver, err := NewContentByConceptService(driver, apigURL)
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
 	assert.NoError(err)
 
-	contentList1, err := contentByConceptDriver.GetContentForConcept(brand1UUID, RequestParams{0, defaultLimit, 0, 0, nil})
+	result1, err := contentByConceptDriver.GetContentForConcept(context.Background(), brand1UUID, RequestParams{0, defaultLimit, 0, 0, nil, SortNewest, 0, "", false})
+	contentList1 := result1.Content
 	assert.NoError(err, "Unexpected error for concept %s", brand1UUID)
 	assert.Equal(1, len(contentList1), "Didn't get the right number of content items, content=%s", contentList1)
 
-	contentList2, err := contentByConceptDriver.GetContentForConcept(topic3UUID, RequestParams{0, defaultLimit, 0, 0, nil})
+	result2, err := contentByConceptDriver.GetContentForConcept(context.Background(), topic3UUID, RequestParams{0, defaultLimit, 0, 0, nil, SortNewest, 0, "", false})
+	contentList2 := result2.Content
 	assert.NoError(err, "Unexpected error for concept %s", topic3UUID)
 	assert.Equal(1, len(contentList2), "Didn't get the right number of content items, content=%s", contentList2)
 
-	contentList3, err := contentByConceptDriver.GetContentForConceptImplicitly(brand1UUID)
+	result3, err := contentByConceptDriver.GetContentForConceptImplicitly(context.Background(), brand1UUID, ImplicitParams{})
+	contentList3 := result3.Content
 	assert.NoError(err, "Unexpected error for concept %s", brand1UUID)
 	assert.Equal(2, len(contentList3), "Didn't get the right number of content items, content=%s", contentList3)
 }
 
+func TestContentIsReturnedImplicitlyForSubsidiaries(t *testing.T) {
+	assert := assert.New(t)
+
+	defer cleanDB(t, content5UUID, content6UUID, FakebookConceptUUID, FakebookPaymentsUUID)
+
+	writeContent(assert, content5UUID)
+	writeContent(assert, content6UUID)
+
+	writeAnnotations(assert, driver, content5UUID, "v2", "./fixtures/Annotations-8a08dfe3-88c4-47dd-bee6-846ede810448-Fakebook.json", nil)
+	writeAnnotations(assert, driver, content6UUID, "v2", "./fixtures/Annotations-27c47a08-6bad-486d-8e06-ce24d583ae2a-FakebookPayments.json", nil)
+
+	writeConcept(assert, driver, "./fixtures/Organisation-Fakebook-eac853f5-3859-4c08-8540-55e043719400.json")
+	writeConcept(assert, driver, "./fixtures/Organisation-FakebookPayments-7b8c6a52-2f4d-4a8e-9c1f-3e5d7a9b1c20.json")
+
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
+	assert.NoError(err)
+
+	result, err := contentByConceptDriver.GetContentForConceptImplicitly(context.Background(), FakebookConceptUUID, ImplicitParams{})
+	assert.NoError(err, "Unexpected error for concept %s", FakebookConceptUUID)
+	assert.Equal(1, len(result.Content), "Subsidiaries should be left out by default, content=%s", result.Content)
+
+	result, err = contentByConceptDriver.GetContentForConceptImplicitly(context.Background(), FakebookConceptUUID, ImplicitParams{SubsidiaryDepth: 1})
+	assert.NoError(err, "Unexpected error for concept %s", FakebookConceptUUID)
+	assert.Equal(2, len(result.Content), "Didn't get the right number of content items, content=%s", result.Content)
+
+	result, err = contentByConceptDriver.GetContentForConceptImplicitly(context.Background(), FakebookPaymentsUUID, ImplicitParams{SubsidiaryDepth: 1})
+	assert.NoError(err, "Unexpected error for concept %s", FakebookPaymentsUUID)
+	assert.Equal(1, len(result.Content), "Parents should not be included, content=%s", result.Content)
+
+	concepts, err := contentByConceptDriver.GetConceptExpansion(context.Background(), FakebookConceptUUID, ImplicitParams{SubsidiaryDepth: 1})
+	assert.NoError(err)
+	assert.Equal([]ExpandedConcept{
+		{ID: ThingsPrefix + FakebookConceptUUID, PrefLabel: "Fakebook, Inc.", Type: "Organisation", Relationships: []string{}},
+		{ID: ThingsPrefix + FakebookPaymentsUUID, PrefLabel: "Fakebook Payments, Inc.", Type: "Organisation", Relationships: []string{"SUB_ORGANISATION_OF"}, Depth: 1},
+	}, concepts)
+}
+
+func TestGetConceptExpansion(t *testing.T) {
+	assert := assert.New(t)
+
+	defer cleanDB(t, topic1UUID, topic2UUID, brand1UUID, topic3UUID)
+
+	writeConcept(assert, driver, "./fixtures/Topic-18e24d65-c8e6-4e23-ab19-206e0d463205.json")
+	writeConcept(assert, driver, "./fixtures/Topic-64ba2208-0c0d-43e2-a883-beecb55c0d33.json")
+	writeConcept(assert, driver, "./fixtures/Brand-5c7592a8-1f0c-11e4-b0cb-b2227cce2b54.json")
+	writeConcept(assert, driver, "./fixtures/Topic-2e7429bd-7a84-41cb-a619-2c702893e359.json")
+
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
+	assert.NoError(err)
+
+	concepts, err := contentByConceptDriver.GetConceptExpansion(context.Background(), topic2UUID, ImplicitParams{})
+	assert.NoError(err)
+	assert.Equal([]ExpandedConcept{
+		{ID: ThingsPrefix + topic2UUID, PrefLabel: "Social affairs", Type: "Topic", Relationships: []string{}},
+		{ID: ThingsPrefix + topic1UUID, PrefLabel: "Demographics and population", Type: "Topic", Relationships: []string{"HAS_BROADER"}, Depth: 1},
+	}, concepts)
+
+	concepts, err = contentByConceptDriver.GetConceptExpansion(context.Background(), brand1UUID, ImplicitParams{})
+	assert.NoError(err)
+	assert.Equal([]ExpandedConcept{
+		{ID: ThingsPrefix + brand1UUID, PrefLabel: "fastFT", Type: "Brand", Relationships: []string{}},
+		{ID: ThingsPrefix + topic3UUID, PrefLabel: "Environment", Type: "Topic", Relationships: []string{"IMPLIED_BY"}, Depth: 1},
+	}, concepts)
+
+	_, err = contentByConceptDriver.GetConceptExpansion(context.Background(), MetalMickeyConceptUUID, ImplicitParams{})
+	assert.Equal(ErrConceptNotFound, err)
+}
+
+func TestReadWithTransactionID(t *testing.T) {
+	assert := assert.New(t)
+	const transID = "tid_metadatatest"
+
+	log := logger.NewUPPLogger("test-service", "warning")
+	hook := logtest.NewLocal(log.Logger)
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL, WithSlowQueryLogging(log, time.Nanosecond))
+	assert.NoError(err)
+
+	ctx := transactionidutils.TransactionAwareContext(context.Background(), transID)
+	info := queryInfo{endpoint: endpointContent, conceptUUID: MSJConceptUUID}
+	for _, profile := range []bool{false, true} {
+		hook.Reset()
+
+		// the transaction the query runs in is listed along with its metadata
+		var results []struct {
+			MetaData map[string]interface{} `json:"metaData"`
+		}
+		query := &Query{
+			Cypher: `
+				CALL dbms.listTransactions() YIELD metaData
+				WHERE metaData.transaction_id = $transID
+				RETURN metaData`,
+			Params: map[string]interface{}{"transID": transID},
+			Result: &results,
+		}
+		_, err := contentByConceptDriver.read(ctx, info, query, profile)
+		assert.NoError(err, "Unexpected error with profile %t", profile)
+		if assert.Len(results, 1, "Missing transaction metadata with profile %t", profile) {
+			expected := map[string]interface{}{"transaction_id": transID, "endpoint": endpointContent, "concept_uuid": MSJConceptUUID}
+			assert.Equal(expected, results[0].MetaData, "Wrong transaction metadata with profile %t", profile)
+		}
+
+		if entry := hook.LastEntry(); assert.NotNil(entry, "Missing slow query log with profile %t", profile) {
+			assert.Contains(entry.Message, "Slow neo4j query took")
+			loggertest.Assert(t, entry).
+				HasTransactionID(transID).
+				HasUUID(MSJConceptUUID).
+				HasField("endpoint", endpointContent).
+				HasField("rows", 1)
+		}
+	}
+}
+
+func TestReadIsTraced(t *testing.T) {
+	assert := assert.New(t)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
+	assert.NoError(err)
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+	info := queryInfo{endpoint: endpointContent, conceptUUID: MSJConceptUUID}
+
+	var results []struct {
+		Value int `json:"value"`
+	}
+	_, err = contentByConceptDriver.read(ctx, info, &Query{Cypher: `UNWIND [1, 2] as value RETURN value`, Result: &results}, false)
+	assert.NoError(err)
+	_, err = contentByConceptDriver.read(ctx, info, &Query{Cypher: `RETURN $missing as value`, Result: &results}, false)
+	assert.Error(err)
+	parent.End()
+
+	spans := recorder.Ended()
+	if !assert.Len(spans, 3) {
+		return
+	}
+	for _, span := range spans[:2] {
+		assert.Equal("neo4j "+endpointContent, span.Name())
+		assert.Equal(trace.SpanKindClient, span.SpanKind())
+		assert.Equal(parent.SpanContext().SpanID(), span.Parent().SpanID(), "The query span should be a child of the request span")
+		assert.Contains(span.Attributes(), semconv.DBSystemNeo4j)
+		assert.Contains(span.Attributes(), attribute.String("concept.uuid", MSJConceptUUID))
+	}
+	assert.Contains(spans[0].Attributes(), attribute.Int("db.rows", 2))
+	assert.Equal(codes.Unset, spans[0].Status().Code)
+	assert.Equal(codes.Error, spans[1].Status().Code)
+	assert.Len(spans[1].Events(), 1, "The query error should be recorded")
+}
+
 func TestConceptService_Check(t *testing.T) {
 	assert := assert.New(t)
-	contentByConceptDriver, err := NewContentByConceptService(driver, apigURL)
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
 	assert.NoError(err)
 	_, err = contentByConceptDriver.CheckConnection()
 	assert.NoError(err, "Test should always pass when connected to db")
 }
 
+func TestConceptService_CheckSchema(t *testing.T) {
+	assert := assert.New(t)
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
+	assert.NoError(err)
+
+	_, err = contentByConceptDriver.SchemaHealth()
+	assert.Error(err, "Indexes should not be reported healthy before they are checked")
+
+	status, err := contentByConceptDriver.CheckSchema()
+	assert.NoError(err, "Test should always pass when connected to db")
+	for _, idx := range status.Missing {
+		createIndex(t, idx)
+	}
+
+	status, err = contentByConceptDriver.CheckSchema()
+	assert.NoError(err)
+	assert.False(status.CheckedAt.IsZero())
+	assert.True(status.OK(), "All required indexes should be online once created, got: %s", status)
+	_, err = contentByConceptDriver.SchemaHealth()
+	assert.NoError(err, "Health should reflect the last schema check")
+
+	dropped := IndexDefinition{Label: "Content", Property: "publishedDateEpoch"}
+	dropIndex(t, dropped)
+	defer createIndex(t, dropped)
+
+	status, err = contentByConceptDriver.CheckSchema()
+	assert.NoError(err)
+	assert.False(status.OK())
+	assert.Equal([]IndexDefinition{dropped}, status.Missing)
+	_, err = contentByConceptDriver.SchemaHealth()
+	assert.EqualError(err, "Content.publishedDateEpoch is missing")
+}
+
+// createIndex creates the index and waits for it to come online.
+func createIndex(t *testing.T, idx IndexDefinition) {
+	err := driver.Write(&cmneo4j.Query{
+		Cypher: fmt.Sprintf("CREATE INDEX IF NOT EXISTS FOR (n:%s) ON (n.%s)", idx.Label, idx.Property),
+	})
+	if err != nil {
+		t.Fatalf("Could not create index %s: %v", idx, err)
+	}
+	if err = driver.Write(&cmneo4j.Query{Cypher: "CALL db.awaitIndexes(60)"}); err != nil {
+		t.Fatalf("Index %s did not come online: %v", idx, err)
+	}
+}
+
+// dropIndex drops the plain indexes of the property, leaving the ones backing constraints.
+func dropIndex(t *testing.T, idx IndexDefinition) {
+	var indexes []struct {
+		Name string `json:"name"`
+	}
+	err := driver.Read(&cmneo4j.Query{
+		Cypher: `SHOW INDEXES YIELD name, labelsOrTypes, properties, uniqueness
+			WHERE labelsOrTypes = [$label] AND properties = [$property] AND uniqueness = 'NONUNIQUE'
+			RETURN name`,
+		Params: map[string]interface{}{"label": idx.Label, "property": idx.Property},
+		Result: &indexes,
+	})
+	if err != nil {
+		t.Fatalf("Could not find index %s: %v", idx, err)
+	}
+	for _, index := range indexes {
+		if err = driver.Write(&cmneo4j.Query{Cypher: "DROP INDEX `" + index.Name + "`"}); err != nil {
+			t.Fatalf("Could not drop index %s: %v", index.Name, err)
+		}
+	}
+}
+
 func TestSVRelationship(t *testing.T) {
 	assert := assert.New(t)
 
@@ -407,10 +1137,11 @@ func TestSVRelationship(t *testing.T) {
 	writeAnnotations(assert, driver, content10UUID, "manual", "./fixtures/Annotations-93e528d3-4ceb-452f-bf88-0ff6b99eab8b-manual.json", []interface{}{svPublicationID})
 	writeConcept(assert, driver, "./fixtures/Sv-provision-a7a8748c-24f9-4034-809b-eb5fcabf96f4.json")
 
-	contentByConceptDriver, err := NewContentByConceptService(driver, apigURL)
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
 	assert.NoError(err)
 
-	contentList, err := contentByConceptDriver.GetContentForConcept(provision1UUID, RequestParams{0, defaultLimit, 0, 0, publication})
+	result, err := contentByConceptDriver.GetContentForConcept(context.Background(), provision1UUID, RequestParams{0, defaultLimit, 0, 0, publication, SortNewest, 0, "", false})
+	contentList := result.Content
 	assert.NoError(err, "Unexpected error for concept %s", provision1UUID)
 	assert.Equal(1, len(contentList), "Didn't get the right number of content items, content=%s", contentList)
 	assertListContainsAll(assert, contentList, getExpectedContent(content10UUID, publication))
@@ -426,10 +1157,11 @@ func TestFTARelationship(t *testing.T) {
 	writeAnnotations(assert, driver, content11UUID, "manual", "./fixtures/Annotations-22e528d3-4ceb-452f-bf88-0ff6b99eab22-manual.json", []interface{}{FTAPublicationUUUID})
 	writeConcept(assert, driver, "./fixtures/FTAGenre-11a8748c-24f9-4034-809b-eb5fcabf9611.json")
 
-	contentByConceptDriver, err := NewContentByConceptService(driver, apigURL)
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
 	assert.NoError(err)
 
-	contentList, err := contentByConceptDriver.GetContentForConcept(FTAGenreUUID, RequestParams{0, defaultLimit, 0, 0, publication})
+	result, err := contentByConceptDriver.GetContentForConcept(context.Background(), FTAGenreUUID, RequestParams{0, defaultLimit, 0, 0, publication, SortNewest, 0, "", false})
+	contentList := result.Content
 	assert.NoError(err, "Unexpected error for concept %s", FTAGenreUUID)
 	assert.Equal(1, len(contentList), "Didn't get the right number of content items, content=%s", contentList)
 	assertListContainsAll(assert, contentList, getExpectedContent(content11UUID, publication))
@@ -445,10 +1177,11 @@ func TestFTPCRelationship(t *testing.T) {
 	writeAnnotations(assert, driver, content12UUID, "manual", "./fixtures/Annotations-3fc9fe3e-af8c-4f7f-961a-e5065392bb33-manual.json", []interface{}{FTPCPublicationUUUID})
 	writeConcept(assert, driver, "./fixtures/FTPCSource-0bbb352d-070d-44de-b5f1-e5b3bef8af40.json")
 
-	contentByConceptDriver, err := NewContentByConceptService(driver, apigURL)
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
 	assert.NoError(err)
 
-	contentList, err := contentByConceptDriver.GetContentForConcept(FTPCSourceUUID, RequestParams{0, defaultLimit, 0, 0, publication})
+	result, err := contentByConceptDriver.GetContentForConcept(context.Background(), FTPCSourceUUID, RequestParams{0, defaultLimit, 0, 0, publication, SortNewest, 0, "", false})
+	contentList := result.Content
 	assert.NoError(err, "Unexpected error for concept %s", FTPCSourceUUID)
 	assert.Equal(1, len(contentList), "Didn't get the right number of content items, content=%s", contentList)
 	assertListContainsAll(assert, contentList, getExpectedContent(content12UUID, publication))
@@ -464,10 +1197,11 @@ func TestGenericPerson(t *testing.T) {
 	writeAnnotations(assert, driver, content12UUID, "manual", "./fixtures/Annotations-3c4666ef-b403-4313-b648-d639762750e4.json", []interface{}{FTPCPublicationUUUID})
 	writeConcept(assert, driver, "./fixtures/Person-3c4666ef-b403-4313-b648-d639762750e4.json")
 
-	contentByConceptDriver, err := NewContentByConceptService(driver, apigURL)
+	contentByConceptDriver, err := NewContentByConceptService(serviceDriver, apigURL)
 	assert.NoError(err)
 
-	contentList, err := contentByConceptDriver.GetContentForConcept(PersonUUID, RequestParams{0, defaultLimit, 0, 0, publication})
+	result, err := contentByConceptDriver.GetContentForConcept(context.Background(), PersonUUID, RequestParams{0, defaultLimit, 0, 0, publication, SortNewest, 0, "", false})
+	contentList := result.Content
 	assert.NoError(err, "Unexpected error for concept %s", PersonUUID)
 	assert.Equal(1, len(contentList), "Didn't get the right number of content items, content=%s", contentList)
 	assertListContainsAll(assert, contentList, getExpectedContent(content12UUID, publication))
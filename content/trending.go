@@ -0,0 +1,108 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// TrendingParams selects the windows concepts are compared in and which of them are ranked.
+// The recent window ends at Until and the baseline window ends where the recent one starts.
+type TrendingParams struct {
+	Window   time.Duration
+	Baseline time.Duration
+	Until    time.Time
+	// ConceptTypes are labels of the canonical concepts, e.g. Organisation. Empty ranks concepts of every type.
+	ConceptTypes []string
+	Publication  []string
+	// MinCount leaves out the concepts with less content in the recent window.
+	MinCount int
+	Limit    int
+}
+
+// TrendingConcept is a canonical concept along with how much content it had in each window.
+// Growth is the rate of content in the recent window relative to the rate in the baseline window.
+type TrendingConcept struct {
+	ID            string  `json:"id"`
+	PrefLabel     string  `json:"prefLabel"`
+	Type          string  `json:"type"`
+	RecentCount   int     `json:"recentCount"`
+	BaselineCount int     `json:"baselineCount"`
+	Growth        float64 `json:"growth"`
+}
+
+type trendingResult struct {
+	UUID          string   `json:"uuid"`
+	PrefLabel     string   `json:"prefLabel"`
+	Types         []string `json:"types"`
+	RecentCount   int      `json:"recentCount"`
+	BaselineCount int      `json:"baselineCount"`
+	Growth        float64  `json:"growth"`
+}
+
+// GetTrendingConcepts returns the canonical concepts whose content grew the most in the recent window compared to
+// the baseline window, with the concordance and publication filtering of GetContentForConcept.
+// The baseline count is smoothed by one so that concepts without content in the baseline window can be ranked.
+func (cd *ConceptService) GetTrendingConcepts(ctx context.Context, params TrendingParams) ([]TrendingConcept, error) {
+	var results []trendingResult
+	query := trendingConceptsQuery(params, &results)
+
+	info := queryInfo{endpoint: endpointTrendingConcepts}
+	_, err := cd.read(ctx, info, query, false)
+	if err != nil && !errors.Is(err, errNoResults) {
+		return nil, err
+	}
+
+	concepts := make([]TrendingConcept, 0, len(results))
+	for _, result := range results {
+		concepts = append(concepts, TrendingConcept{
+			ID:            idURL(result.UUID, cd.thingsURL),
+			PrefLabel:     result.PrefLabel,
+			Type:          mostSpecificType(result.Types),
+			RecentCount:   result.RecentCount,
+			BaselineCount: result.BaselineCount,
+			Growth:        result.Growth,
+		})
+	}
+	return concepts, nil
+}
+
+func trendingConceptsQuery(params TrendingParams, results *[]trendingResult) *Query {
+	filter, publication := contentFilter(false, params.Publication)
+	var typeFilter string
+	if len(params.ConceptTypes) > 0 {
+		typeFilter = " WHERE any(label IN labels(canon) WHERE label IN $types)"
+	}
+
+	windowFrom := params.Until.Add(-params.Window)
+	return &Query{
+		Cypher: `
+			MATCH (c:Content)
+			WHERE c.publishedDateEpoch > $baselineFrom AND c.publishedDateEpoch <= $until
+				AND NOT 'LiveEvent' IN labels(c)` +
+			filter +
+			` MATCH (c)-[]->(:Concept)-[:EQUIVALENT_TO]->(canon:Concept)` +
+			typeFilter +
+			` WITH DISTINCT canon, c
+			WITH canon, sum(CASE WHEN c.publishedDateEpoch > $windowFrom THEN 1 ELSE 0 END) as recentCount, count(c) as total
+			WHERE recentCount >= $minCount
+			WITH canon, recentCount, total - recentCount as baselineCount
+			WITH canon, recentCount, baselineCount,
+				(toFloat(recentCount) / $window) / ((baselineCount + 1.0) / $baseline) as growth
+			RETURN canon.prefUUID as uuid, canon.prefLabel as prefLabel, labels(canon) as types, recentCount, baselineCount, growth
+			ORDER BY growth DESC, recentCount DESC, uuid
+			LIMIT $limit`,
+		Params: map[string]interface{}{
+			"baselineFrom": windowFrom.Add(-params.Baseline).Unix(),
+			"windowFrom":   windowFrom.Unix(),
+			"until":        params.Until.Unix(),
+			"window":       params.Window.Seconds(),
+			"baseline":     params.Baseline.Seconds(),
+			"publication":  publication,
+			"types":        params.ConceptTypes,
+			"minCount":     params.MinCount,
+			"limit":        params.Limit,
+		},
+		Result: results,
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/Financial-Times/go-logger/v2"
+	"github.com/Financial-Times/public-content-by-concept-api/v2/content"
+	transactionidutils "github.com/Financial-Times/transactionid-utils-go"
+)
+
+const (
+	defaultCooccurrenceLimit = 20
+	maxCooccurrenceLimit     = 100
+)
+
+// cooccurrenceParams are the query parameters accepted by the co-occurring concepts endpoint.
+var cooccurrenceParams = []string{"fromDate", "toDate", "publication", "type", "predicate", "limit", "strict"}
+
+type cooccurrenceResponse struct {
+	Concepts []content.CooccurringConcept `json:"concepts"`
+}
+
+// GetCooccurringConcepts returns the concepts annotating the most content along with the concept in the path.
+func (h *Handler) GetCooccurringConcepts(w http.ResponseWriter, r *http.Request) {
+	transID := transactionidutils.GetTransactionIDFromRequest(r)
+	ctx := transactionidutils.TransactionAwareContext(r.Context(), transID)
+
+	logEntry := h.Log.WithTransactionID(transID)
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set(transactionidutils.TransactionIDHeader, transID)
+	logEntry.Debugf("Request url is %s", r.URL.RawQuery)
+
+	conceptUUID := strings.TrimPrefix(mux.Vars(r)["conceptUUID"], thingURIPrefix)
+	if !UUIDRegex.MatchString(conceptUUID) {
+		writeProblem(ctx, w, http.StatusBadRequest, "conceptUUID", fmt.Sprintf("%s extracted from request URL was not valid uuid", conceptUUID))
+		return
+	}
+	logEntry = logEntry.WithUUID(conceptUUID)
+
+	m, err := url.ParseQuery(r.URL.RawQuery)
+	if err != nil {
+		logEntry.WithError(err).Error("Could not parse request url")
+		writeProblem(ctx, w, http.StatusBadRequest, "", "Could not parse the request query")
+		return
+	}
+
+	strict, err := h.strictRequested(m, cooccurrenceParams)
+	if err != nil {
+		writeRequestError(ctx, w, err)
+		return
+	}
+
+	params, err := h.extractCooccurrenceParams(m, strict, logEntry)
+	if err != nil {
+		writeRequestError(ctx, w, err)
+		return
+	}
+
+	dbStart := time.Now()
+	concepts, err := h.ContentService.GetCooccurringConcepts(ctx, conceptUUID, params)
+	recordTiming(ctx, timingDB, dbStart)
+	switch {
+	case errors.Is(err, content.ErrConceptNotFound):
+		msg := fmt.Sprintf("No concept found with uuid %s", conceptUUID)
+		logEntry.Debugf(msg)
+		writeConceptNotFound(ctx, w, msg)
+		return
+	case err != nil:
+		msg := fmt.Sprintf("Backend error returning co-occurring concepts for concept with uuid %s", conceptUUID)
+		logEntry.WithError(err).Error(msg)
+		writeProblem(ctx, w, http.StatusServiceUnavailable, "", msg)
+		return
+	}
+
+	encoded, err := encodeJSON(ctx, cooccurrenceResponse{Concepts: concepts})
+	if err != nil {
+		msg := fmt.Sprintf("Error parsing returned co-occurring concepts for concept with uuid %s", conceptUUID)
+		logEntry.WithError(err).Error(msg)
+		writeProblem(ctx, w, http.StatusInternalServerError, "", msg)
+		return
+	}
+
+	w.Header().Set("Cache-Control", h.CacheControlHeader)
+	writeServerTiming(ctx, w)
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(encoded); err != nil {
+		logEntry.WithError(err).Errorf("Error writing co-occurring concepts for concept with uuid %s", conceptUUID)
+	}
+}
+
+// extractCooccurrenceParams validates the filters of the co-occurring concepts endpoint. Dates and publications
+// are validated as /content does.
+func (h *Handler) extractCooccurrenceParams(val url.Values, strict bool, log *logger.LogEntry) (content.CooccurrenceParams, error) {
+	limit, err := intParam(val, "limit", defaultCooccurrenceLimit, 1, maxCooccurrenceLimit)
+	if err != nil {
+		return content.CooccurrenceParams{}, err
+	}
+
+	fromDateEpoch, toDateEpoch, dated, err := extractDates(val, strict, log)
+	if err != nil {
+		return content.CooccurrenceParams{}, err
+	}
+
+	publication, err := extractPublication(val, log)
+	if err != nil {
+		return content.CooccurrenceParams{}, err
+	}
+
+	types, err := typeParam(val, "concept")
+	if err != nil {
+		return content.CooccurrenceParams{}, err
+	}
+
+	predicates, err := predicateParam(val)
+	if err != nil {
+		return content.CooccurrenceParams{}, err
+	}
+
+	return content.CooccurrenceParams{
+		FromDateEpoch: fromDateEpoch,
+		ToDateEpoch:   toDateEpoch,
+		Dated:         dated,
+		Publication:   publication,
+		ConceptTypes:  types,
+		Predicates:    predicates,
+		Limit:         limit,
+	}, nil
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Financial-Times/go-logger/v2"
+	"github.com/Financial-Times/public-content-by-concept-api/v2/content"
+	transactionidutils "github.com/Financial-Times/transactionid-utils-go"
+)
+
+// countParams are the query parameters accepted by the count endpoint.
+var countParams = []string{"isAnnotatedBy", "fromDate", "toDate", "publication", "type", "predicate", "implicit", "strict"}
+
+var typeNameRegex = regexp.MustCompile(`^[A-Za-z]+$`)
+
+type countResponse struct {
+	Counts map[string]int `json:"counts"`
+}
+
+// GetContentCount returns how much content each of the concepts in isAnnotatedBy has, keyed by their isAnnotatedBy.
+// Concepts that do not exist are left out.
+func (h *Handler) GetContentCount(w http.ResponseWriter, r *http.Request) {
+	transID := transactionidutils.GetTransactionIDFromRequest(r)
+	ctx := transactionidutils.TransactionAwareContext(r.Context(), transID)
+
+	logEntry := h.Log.WithTransactionID(transID)
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set(transactionidutils.TransactionIDHeader, transID)
+
+	m, err := url.ParseQuery(r.URL.RawQuery)
+	if err != nil {
+		logEntry.WithError(err).Error("Could not parse request url")
+		writeProblem(ctx, w, http.StatusBadRequest, "", "Could not parse the request query")
+		return
+	}
+	logEntry.Debugf("Request url is %s", r.URL.RawQuery)
+
+	strict, err := h.strictRequested(m, countParams)
+	if err != nil {
+		writeRequestError(ctx, w, err)
+		return
+	}
+
+	conceptURIs := listParam(m, "isAnnotatedBy")
+	if len(conceptURIs) == 0 {
+		writeProblem(ctx, w, http.StatusBadRequest, "isAnnotatedBy", "Missing or empty query parameter isAnnotatedBy. Expecting valid absolute concept URI.")
+		return
+	}
+	if h.MaxBatchSize > 0 && len(conceptURIs) > h.MaxBatchSize {
+		writeProblem(ctx, w, http.StatusBadRequest, "isAnnotatedBy", fmt.Sprintf("%d concepts exceed the maximum of %d", len(conceptURIs), h.MaxBatchSize))
+		return
+	}
+
+	// keys are the isAnnotatedBy values the counts are returned for, by concept UUID
+	keys := make(map[string]string, len(conceptURIs))
+	conceptUUIDs := make([]string, 0, len(conceptURIs))
+	for _, conceptURI := range conceptURIs {
+		ref, err := parseConceptRef(conceptURI)
+		if err != nil {
+			writeRequestError(ctx, w, err)
+			return
+		}
+		if ref.uuid == "" {
+			writeProblem(ctx, w, http.StatusBadRequest, "isAnnotatedBy", "Authority identifiers are not supported when counting. Expecting a concept UUID or URI.")
+			return
+		}
+		if _, duplicate := keys[ref.uuid]; duplicate {
+			continue
+		}
+		keys[ref.uuid] = conceptURI
+		conceptUUIDs = append(conceptUUIDs, ref.uuid)
+	}
+
+	params, err := h.extractCountParams(m, strict, logEntry)
+	if err != nil {
+		writeRequestError(ctx, w, err)
+		return
+	}
+
+	dbStart := time.Now()
+	counts, err := h.ContentService.CountContentForConcepts(ctx, conceptUUIDs, params)
+	recordTiming(ctx, timingDB, dbStart)
+	if err != nil {
+		msg := "Backend error counting content for concepts"
+		logEntry.WithError(err).Error(msg)
+		writeProblem(ctx, w, http.StatusServiceUnavailable, "", msg)
+		return
+	}
+
+	response := countResponse{Counts: make(map[string]int, len(counts))}
+	for conceptUUID, count := range counts {
+		response.Counts[keys[conceptUUID]] = count
+	}
+
+	encoded, err := encodeJSON(ctx, response)
+	if err != nil {
+		msg := "Error parsing returned content counts"
+		logEntry.WithError(err).Error(msg)
+		writeProblem(ctx, w, http.StatusInternalServerError, "", msg)
+		return
+	}
+
+	w.Header().Set("Cache-Control", h.CacheControlHeader)
+	writeServerTiming(ctx, w)
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(encoded); err != nil {
+		logEntry.WithError(err).Error("Error writing content counts")
+	}
+}
+
+// extractCountParams validates the filters of the count endpoint. Dates and publications are validated as /content does.
+// Types and predicates can be given either by name or by their FT ontology URI.
+func (h *Handler) extractCountParams(val url.Values, strict bool, log *logger.LogEntry) (content.CountParams, error) {
+	fromDateEpoch, toDateEpoch, dated, err := extractDates(val, strict, log)
+	if err != nil {
+		return content.CountParams{}, err
+	}
+
+	publication, err := extractPublication(val, log)
+	if err != nil {
+		return content.CountParams{}, err
+	}
+
+	types, err := typeParam(val, "content")
+	if err != nil {
+		return content.CountParams{}, err
+	}
+
+	predicates, err := predicateParam(val)
+	if err != nil {
+		return content.CountParams{}, err
+	}
+
+	implicit, err := boolParam(val, "implicit")
+	if err != nil {
+		return content.CountParams{}, err
+	}
+
+	return content.CountParams{
+		FromDateEpoch: fromDateEpoch,
+		ToDateEpoch:   toDateEpoch,
+		Dated:         dated,
+		Publication:   publication,
+		ContentTypes:  types,
+		Predicates:    predicates,
+		Implicit:      implicit,
+	}, nil
+}
+
+// typeParam returns the names of the types asked for, which can be given either by name, e.g. Article,
+// or by their FT ontology URI, e.g. http://www.ft.com/ontology/content/Article. kind names the types in errors.
+func typeParam(val url.Values, kind string) ([]string, error) {
+	var types []string
+	for _, t := range listParam(val, "type") {
+		name := path.Base(t)
+		if !typeNameRegex.MatchString(name) {
+			return nil, newParamError("type", "%s is not a valid %s type", t, kind)
+		}
+		types = append(types, name)
+	}
+	return types, nil
+}
+
+// predicateParam returns the annotation predicates asked for, which can be given either by name, e.g. about,
+// or by their FT ontology URI, e.g. http://www.ft.com/ontology/annotation/about.
+func predicateParam(val url.Values) ([]string, error) {
+	var predicates []string
+	for _, predicate := range listParam(val, "predicate") {
+		name := path.Base(predicate)
+		if !slices.Contains(content.Predicates(), name) {
+			return nil, newParamError("predicate", "%s is not a supported predicate. Expecting one of: %s", predicate, strings.Join(content.Predicates(), ", "))
+		}
+		predicates = append(predicates, name)
+	}
+	return predicates, nil
+}
+
+// listParam returns the values of a parameter that can be repeated and given as a comma separated list.
+func listParam(val url.Values, name string) []string {
+	var values []string
+	for _, param := range val[name] {
+		for _, value := range strings.Split(param, ",") {
+			if value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/Financial-Times/public-content-by-concept-api/v2/content"
+	transactionidutils "github.com/Financial-Times/transactionid-utils-go"
+)
+
+// expansionParams are the query parameters accepted by the concept expansion endpoint.
+var expansionParams = []string{"subsidiaryDepth", "strict"}
+
+type expansionResponse struct {
+	Concepts []content.ExpandedConcept `json:"concepts"`
+}
+
+// GetConceptExpansion returns the concepts /content/{conceptUUID}/implicitly returns the content of for the concept in the path.
+func (h *Handler) GetConceptExpansion(w http.ResponseWriter, r *http.Request) {
+	transID := transactionidutils.GetTransactionIDFromRequest(r)
+	ctx := transactionidutils.TransactionAwareContext(r.Context(), transID)
+
+	logEntry := h.Log.WithTransactionID(transID)
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set(transactionidutils.TransactionIDHeader, transID)
+	logEntry.Debugf("Request url is %s", r.URL.RawQuery)
+
+	conceptUUID := strings.TrimPrefix(mux.Vars(r)["conceptUUID"], thingURIPrefix)
+	if !UUIDRegex.MatchString(conceptUUID) {
+		writeProblem(ctx, w, http.StatusBadRequest, "conceptUUID", fmt.Sprintf("%s extracted from request URL was not valid uuid", conceptUUID))
+		return
+	}
+	logEntry = logEntry.WithUUID(conceptUUID)
+
+	if _, err := h.strictRequested(r.URL.Query(), expansionParams); err != nil {
+		writeRequestError(ctx, w, err)
+		return
+	}
+
+	params, err := h.extractImplicitParams(r.URL.Query())
+	if err != nil {
+		writeRequestError(ctx, w, err)
+		return
+	}
+
+	dbStart := time.Now()
+	concepts, err := h.ContentService.GetConceptExpansion(ctx, conceptUUID, params)
+	recordTiming(ctx, timingDB, dbStart)
+	switch {
+	case errors.Is(err, content.ErrConceptNotFound):
+		msg := fmt.Sprintf("No concept found with uuid %s", conceptUUID)
+		logEntry.Debugf(msg)
+		writeConceptNotFound(ctx, w, msg)
+		return
+	case err != nil:
+		msg := fmt.Sprintf("Backend error returning expansion for concept with uuid %s", conceptUUID)
+		logEntry.WithError(err).Error(msg)
+		writeProblem(ctx, w, http.StatusServiceUnavailable, "", msg)
+		return
+	}
+
+	encoded, err := encodeJSON(ctx, expansionResponse{Concepts: concepts})
+	if err != nil {
+		msg := fmt.Sprintf("Error parsing returned expansion for concept with uuid %s", conceptUUID)
+		logEntry.WithError(err).Error(msg)
+		writeProblem(ctx, w, http.StatusInternalServerError, "", msg)
+		return
+	}
+
+	w.Header().Set("Cache-Control", h.CacheControlHeader)
+	writeServerTiming(ctx, w)
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(encoded); err != nil {
+		logEntry.WithError(err).Errorf("Error writing expansion for concept with uuid %s", conceptUUID)
+	}
+}
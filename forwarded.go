@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Financial-Times/public-content-by-concept-api/v2/content"
+	"github.com/gorilla/mux"
+)
+
+const (
+	forwardedHostHeader  = "X-Forwarded-Host"
+	forwardedProtoHeader = "X-Forwarded-Proto"
+)
+
+// forwardedAPIURL is a mux middleware building the apiUrl fields of the response from the X-Forwarded-Host
+// and X-Forwarded-Proto headers for requests forwarded by one of the trusted hosts. Other requests get the
+// configured API URL. As responses then depend on those headers, they vary by them for shared caches.
+func forwardedAPIURL(trustedHosts []string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", forwardedHostHeader+", "+forwardedProtoHeader)
+			// proxies append to the headers, the first value is the one the client used
+			host := firstHeaderValue(r, forwardedHostHeader)
+			trusted := slices.ContainsFunc(trustedHosts, func(trustedHost string) bool {
+				return strings.EqualFold(trustedHost, host)
+			})
+			if host != "" && trusted {
+				proto := "https"
+				if strings.EqualFold(firstHeaderValue(r, forwardedProtoHeader), "http") {
+					proto = "http"
+				}
+				r = r.WithContext(content.ContextWithAPIURL(r.Context(), proto+"://"+host))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func firstHeaderValue(r *http.Request, header string) string {
+	value, _, _ := strings.Cut(r.Header.Get(header), ",")
+	return strings.TrimSpace(value)
+}
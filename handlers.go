@@ -1,20 +1,25 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/codes"
 
 	"github.com/Financial-Times/go-logger/v2"
 	"github.com/Financial-Times/public-content-by-concept-api/v2/content"
+	"github.com/Financial-Times/public-content-by-concept-api/v2/problem"
 	transactionidutils "github.com/Financial-Times/transactionid-utils-go"
 )
 
@@ -23,82 +28,191 @@ const (
 	defaultLimit   = 50
 	thingURIPrefix = "http://api.ft.com/things/"
 	dateTimeLayout = "2006-01-02"
+
+	debugProfile     = "profile"
+	accessFromHeader = "Access-From"
+	apiGatewayAccess = "API Gateway"
+
+	resultCountHeader   = "X-Result-Count"
+	canonicalUUIDHeader = "X-Canonical-Concept-UUID"
+	resolvedFromHeader  = "X-Concept-Resolved-From"
 )
 
 var UUIDRegex = regexp.MustCompile(`([0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})$`)
 
+// ftURIRegex matches the FT URIs of a concept, e.g. http://www.ft.com/things/{uuid} or https://api.ft.com/people/{uuid},
+// under any of the paths concepts are published on.
+var ftURIRegex = regexp.MustCompile(`^https?://(?:www|api)\.ft\.com/(?:things|concepts|people|organisations|brands|genres|locations|topics|subjects|sections|special-reports|alphaville-series|memberships|roles|financial-instruments)/([0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})$`)
+
+var (
+	errDebugForbidden       = errors.New("debug mode is only available to admin requests")
+	errPublicationForbidden = errors.New("publication is not allowed by the access policy")
+)
+
+// Query parameters accepted by the content endpoints. Any other parameter is rejected in strict mode.
+var (
+	contentParams         = []string{"isAnnotatedBy", "page", "limit", "fromDate", "toDate", "publication", "sort", "maxPerGroup", "groupBy", "allowEmpty", "includeConcept", "includeAnnotations", "debug", "strict"}
+	implicitContentParams = []string{"allowEmpty", "includeConcept", "includeAnnotations", "subsidiaryDepth", "debug", "strict"}
+)
+
 type dbContentForConceptGetter interface {
-	GetContentForConcept(conceptUUID string, params content.RequestParams) ([]content.Content, error)
-	GetContentForConceptImplicitly(conceptUUID string) ([]content.Content, error)
+	GetContentForConcept(ctx context.Context, conceptUUID string, params content.RequestParams) (content.ConceptContent, error)
+	GetContentForConceptImplicitly(ctx context.Context, conceptUUID string, params content.ImplicitParams) (content.ConceptContent, error)
+	ProfileContentForConcept(ctx context.Context, conceptUUID string, params content.RequestParams) (content.ConceptContent, *content.QueryProfile, error)
+	ProfileContentForConceptImplicitly(ctx context.Context, conceptUUID string, params content.ImplicitParams) (content.ConceptContent, *content.QueryProfile, error)
+	ConceptUUIDForIdentifier(ctx context.Context, authority, identifier string) (string, error)
+	CanonicalUUID(ctx context.Context, conceptUUID string) (string, error)
+	GetContentForConcepts(ctx context.Context, queries []content.ConceptQuery) []content.ConceptQueryResult
+	CountContentForConcepts(ctx context.Context, conceptUUIDs []string, params content.CountParams) (map[string]int, error)
+	GetContentHistogram(ctx context.Context, conceptUUID string, params content.HistogramParams) ([]content.HistogramBucket, error)
+	GetTrendingConcepts(ctx context.Context, params content.TrendingParams) ([]content.TrendingConcept, error)
+	GetCooccurringConcepts(ctx context.Context, conceptUUID string, params content.CooccurrenceParams) ([]content.CooccurringConcept, error)
+	GetConceptExpansion(ctx context.Context, conceptUUID string, params content.ImplicitParams) ([]content.ExpandedConcept, error)
 }
 
 type Handler struct {
 	ContentService     dbContentForConceptGetter
 	CacheControlHeader string
 	Log                *logger.UPPLogger
+	QueryDebugEnabled  bool
+	// RedirectToCanonical answers requests for concepts concorded into another canonical concept with a redirect
+	// to the canonical concept instead of its content.
+	RedirectToCanonical bool
+
+	// StrictValidation validates every request strictly. Requests can also ask for it with strict=true.
+	StrictValidation bool
+	// MaxLimit and MaxPageDepth cap limit and page in strict mode. Zero means no cap.
+	MaxLimit     int
+	MaxPageDepth int
+	// MaxBatchSize caps how many concepts a batch or a count can ask for. Zero means no cap.
+	MaxBatchSize int
+	// MaxSubsidiaryDepth caps how many levels of subsidiaries the implicit content can include. Zero disables them.
+	MaxSubsidiaryDepth int
+}
+
+// conceptContent is the envelope content is returned in when the concept is asked for.
+type conceptContent struct {
+	Concept *content.Concept  `json:"concept"`
+	Content []content.Content `json:"content"`
+}
+
+type profiledContent struct {
+	Concept *content.Concept      `json:"concept,omitempty"`
+	Content []content.Content     `json:"content"`
+	Profile *content.QueryProfile `json:"profile"`
+}
+
+// responseOptions are what the request asks to be included in the response.
+type responseOptions struct {
+	// allowEmpty answers with an empty list instead of 404 when the concept exists but has no content.
+	allowEmpty bool
+	// includeConcept returns the content in an envelope along with the canonical concept.
+	includeConcept bool
 }
 
 func (h *Handler) GetContentByConcept(w http.ResponseWriter, r *http.Request) {
 	transID := transactionidutils.GetTransactionIDFromRequest(r)
+	ctx := transactionidutils.TransactionAwareContext(r.Context(), transID)
 
 	logEntry := h.Log.WithTransactionID(transID)
 
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set(transactionidutils.TransactionIDHeader, transID)
+
 	m, err := url.ParseQuery(r.URL.RawQuery)
 	if err != nil {
 		logEntry.WithError(err).Error("Could not parse request url")
+		writeProblem(ctx, w, http.StatusBadRequest, "", "Could not parse the request query")
 		return
 	}
-	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
-	w.Header().Set(transactionidutils.TransactionIDHeader, transID)
 	logEntry.Debugf("Request url is %s", r.URL.RawQuery)
 
+	strict, err := h.strictRequested(m, contentParams)
+	if err != nil {
+		writeRequestError(ctx, w, err)
+		return
+	}
+
 	conceptURI := m.Get("isAnnotatedBy")
 	if conceptURI == "" {
-		writeJSONMessage(w, http.StatusBadRequest, "Missing or empty query parameter isAnnotatedBy. Expecting valid absolute concept URI.")
+		writeProblem(ctx, w, http.StatusBadRequest, "isAnnotatedBy", "Missing or empty query parameter isAnnotatedBy. Expecting valid absolute concept URI.")
 		return
 	}
 
-	conceptUUID := strings.TrimPrefix(conceptURI, thingURIPrefix)
-	if !UUIDRegex.MatchString(conceptUUID) {
-		writeJSONMessage(w, http.StatusBadRequest, fmt.Sprintf("%s extracted from request URL was not valid uuid", conceptUUID))
+	ref, err := parseConceptRef(conceptURI)
+	if err != nil {
+		writeRequestError(ctx, w, err)
+		return
+	}
+
+	requestParams, err := h.extractRequestParams(m, strict, logEntry)
+	if err != nil {
+		writeRequestError(ctx, w, err)
 		return
 	}
-	logEntry = logEntry.WithUUID(conceptUUID)
 
-	requestParams, err := extractRequestParams(m, logEntry)
+	opts, err := parseResponseOptions(m)
 	if err != nil {
-		writeJSONMessage(w, http.StatusBadRequest, err.Error())
+		writeRequestError(ctx, w, err)
 		return
 	}
 
-	contentList, err := h.ContentService.GetContentForConcept(conceptUUID, requestParams)
+	profile, err := h.profileRequested(r, m)
 	if err != nil {
-		if err == content.ErrContentNotFound {
-			msg := fmt.Sprintf("No content found for concept with uuid %s", conceptUUID)
+		writeRequestError(ctx, w, err)
+		return
+	}
+
+	conceptUUID, err := h.resolveConceptRef(ctx, ref)
+	if err != nil {
+		if errors.Is(err, content.ErrConceptNotFound) {
+			msg := fmt.Sprintf("No concept found with identifier %s", conceptURI)
 			logEntry.Debugf(msg)
-			writeJSONMessage(w, http.StatusNotFound, msg)
+			writeConceptNotFound(ctx, w, msg)
+			return
+		}
+		if errors.Is(err, content.ErrAmbiguousIdentifier) {
+			msg := fmt.Sprintf("More than one concept found with identifier %s", conceptURI)
+			logEntry.Debugf(msg)
+			writeProblem(ctx, w, http.StatusConflict, "isAnnotatedBy", msg)
 			return
 		}
 
-		msg := fmt.Sprintf("Backend error returning content for concept with uuid %s", conceptUUID)
+		msg := fmt.Sprintf("Backend error resolving concept with identifier %s", conceptURI)
 		logEntry.WithError(err).Error(msg)
-		writeJSONMessage(w, http.StatusServiceUnavailable, msg)
+		writeProblem(ctx, w, http.StatusServiceUnavailable, "", msg)
 		return
 	}
+	logEntry = logEntry.WithUUID(conceptUUID)
 
-	w.Header().Set("Cache-Control", h.CacheControlHeader)
-	w.WriteHeader(http.StatusOK)
+	// concepts referred to by an authority identifier are already resolved to their canonical concept
+	if !profile && ref.uuid != "" {
+		responded := h.redirectToCanonical(ctx, w, conceptUUID, func(canonicalUUID string) string {
+			query, _ := url.ParseQuery(clientQuery(r))
+			query.Set("isAnnotatedBy", strings.Replace(conceptURI, conceptUUID, canonicalUUID, 1))
+			return r.URL.Path + "?" + query.Encode()
+		}, logEntry)
+		if responded {
+			return
+		}
+	}
 
-	if err = json.NewEncoder(w).Encode(contentList); err != nil {
-		msg := fmt.Sprintf("Error parsing returned content list for concept with uuid %s", conceptUUID)
-		logEntry.WithError(err).Error(msg)
-		writeJSONMessage(w, http.StatusInternalServerError, msg)
+	dbStart := time.Now()
+	if profile {
+		result, queryProfile, err := h.ContentService.ProfileContentForConcept(ctx, conceptUUID, requestParams)
+		recordTiming(ctx, timingDB, dbStart)
+		h.writeProfiledContent(ctx, w, result, queryProfile, err, opts, conceptUUID, logEntry)
 		return
 	}
+
+	result, err := h.ContentService.GetContentForConcept(ctx, conceptUUID, requestParams)
+	recordTiming(ctx, timingDB, dbStart)
+	h.writeContentResult(ctx, w, result, err, opts, conceptUUID, logEntry)
 }
 
 func (h *Handler) GetContentByConceptImplicitly(w http.ResponseWriter, r *http.Request) {
 	transID := transactionidutils.GetTransactionIDFromRequest(r)
+	ctx := transactionidutils.TransactionAwareContext(r.Context(), transID)
 
 	logEntry := h.Log.WithTransactionID(transID)
 
@@ -110,45 +224,340 @@ func (h *Handler) GetContentByConceptImplicitly(w http.ResponseWriter, r *http.R
 	conceptUUID := vars["conceptUUID"]
 	conceptUUID = strings.TrimPrefix(conceptUUID, thingURIPrefix)
 	if !UUIDRegex.MatchString(conceptUUID) {
-		writeJSONMessage(w, http.StatusBadRequest, fmt.Sprintf("%s extracted from request URL was not valid uuid", conceptUUID))
+		writeProblem(ctx, w, http.StatusBadRequest, "conceptUUID", fmt.Sprintf("%s extracted from request URL was not valid uuid", conceptUUID))
 		return
 	}
 	logEntry = logEntry.WithUUID(conceptUUID)
 
-	contentList, err := h.ContentService.GetContentForConceptImplicitly(conceptUUID)
+	if _, err := h.strictRequested(r.URL.Query(), implicitContentParams); err != nil {
+		writeRequestError(ctx, w, err)
+		return
+	}
+
+	opts, err := parseResponseOptions(r.URL.Query())
 	if err != nil {
-		if err == content.ErrContentNotFound {
-			msg := fmt.Sprintf("No content found for concept with uuid %s", conceptUUID)
-			logEntry.Debugf(msg)
-			writeJSONMessage(w, http.StatusNotFound, msg)
+		writeRequestError(ctx, w, err)
+		return
+	}
+
+	implicitParams, err := h.extractImplicitParams(r.URL.Query())
+	if err != nil {
+		writeRequestError(ctx, w, err)
+		return
+	}
+	implicitParams.IncludeAnnotations, err = boolParam(r.URL.Query(), "includeAnnotations")
+	if err != nil {
+		writeRequestError(ctx, w, err)
+		return
+	}
+
+	profile, err := h.profileRequested(r, r.URL.Query())
+	if err != nil {
+		writeRequestError(ctx, w, err)
+		return
+	}
+
+	if !profile {
+		responded := h.redirectToCanonical(ctx, w, conceptUUID, func(canonicalUUID string) string {
+			location := strings.Replace(r.URL.Path, conceptUUID, canonicalUUID, 1)
+			if query := clientQuery(r); query != "" {
+				location += "?" + query
+			}
+			return location
+		}, logEntry)
+		if responded {
 			return
 		}
+	}
+
+	dbStart := time.Now()
+	if profile {
+		result, queryProfile, err := h.ContentService.ProfileContentForConceptImplicitly(ctx, conceptUUID, implicitParams)
+		recordTiming(ctx, timingDB, dbStart)
+		h.writeProfiledContent(ctx, w, result, queryProfile, err, opts, conceptUUID, logEntry)
+		return
+	}
+
+	result, err := h.ContentService.GetContentForConceptImplicitly(ctx, conceptUUID, implicitParams)
+	recordTiming(ctx, timingDB, dbStart)
+	h.writeContentResult(ctx, w, result, err, opts, conceptUUID, logEntry)
+}
+
+// extractImplicitParams validates subsidiaryDepth, which is rejected whatever the validation mode when it is over
+// MaxSubsidiaryDepth as every level multiplies the content the query looks at.
+func (h *Handler) extractImplicitParams(val url.Values) (content.ImplicitParams, error) {
+	depth, err := intParam(val, "subsidiaryDepth", 0, 0, 0)
+	if err != nil {
+		return content.ImplicitParams{}, err
+	}
+	if depth > h.MaxSubsidiaryDepth {
+		return content.ImplicitParams{}, newParamError("subsidiaryDepth", "provided value for subsidiaryDepth should not be greater than: %d", h.MaxSubsidiaryDepth)
+	}
+	return content.ImplicitParams{SubsidiaryDepth: depth}, nil
+}
+
+// profileRequested reports whether the request asks for the query profile with debug=profile.
+// Profiles are only returned when enabled and only to admin requests, i.e. ones that did not come through the API Gateway.
+func (h *Handler) profileRequested(r *http.Request, val url.Values) (bool, error) {
+	debug := val.Get("debug")
+	if debug == "" {
+		return false, nil
+	}
+	if debug != debugProfile {
+		return false, newParamError("debug", "provided value for debug, %s, is not supported. Expecting %s", debug, debugProfile)
+	}
+	if !h.QueryDebugEnabled || r.Header.Get(accessFromHeader) == apiGatewayAccess {
+		return false, errDebugForbidden
+	}
+	return true, nil
+}
+
+func parseResponseOptions(val url.Values) (responseOptions, error) {
+	allowEmpty, err := boolParam(val, "allowEmpty")
+	if err != nil {
+		return responseOptions{}, err
+	}
+	includeConcept, err := boolParam(val, "includeConcept")
+	if err != nil {
+		return responseOptions{}, err
+	}
+	return responseOptions{allowEmpty: allowEmpty, includeConcept: includeConcept}, nil
+}
+
+// body returns the content list, or the envelope with the concept when it is asked for.
+func (opts responseOptions) body(result content.ConceptContent) interface{} {
+	if !opts.includeConcept {
+		return result.Content
+	}
+	return conceptContent{Concept: result.Concept, Content: result.Content}
+}
+
+// boolParam parses the optional boolean query parameter, which defaults to false.
+func boolParam(val url.Values, name string) (bool, error) {
+	param := val.Get(name)
+	if param == "" {
+		return false, nil
+	}
+	value, err := strconv.ParseBool(param)
+	if err != nil {
+		return false, newParamError(name, "provided value for %s, %s, could not be parsed. Expecting true or false", name, param)
+	}
+	return value, nil
+}
+
+// writeContentResult responds with the content found for the concept or with the error finding it.
+// Unknown concepts are not found, as are concepts without content unless allowEmpty is set.
+func (h *Handler) writeContentResult(ctx context.Context, w http.ResponseWriter, result content.ConceptContent, err error, opts responseOptions, conceptUUID string, logEntry *logger.LogEntry) {
+	if p := contentProblem(err, opts, conceptUUID, logEntry); p != nil {
+		writeDetails(ctx, w, *p)
+		return
+	}
+
+	if result.Content == nil {
+		result.Content = []content.Content{}
+	}
+	h.writeContent(ctx, w, result, opts.body(result), h.CacheControlHeader, conceptUUID, logEntry)
+}
+
+// contentProblem describes the error finding content for the concept. It returns nil when there is content to respond with,
+// which includes concepts without content when allowEmpty is set.
+func contentProblem(err error, opts responseOptions, conceptUUID string, logEntry *logger.LogEntry) *problem.Details {
+	var p problem.Details
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, content.ErrConceptNotFound):
+		p = problem.New(http.StatusNotFound, fmt.Sprintf("No concept found with uuid %s", conceptUUID)).WithType(problem.TypeConceptNotFound)
+		logEntry.Debugf(p.Detail)
+	case errors.Is(err, content.ErrContentNotFound) && opts.allowEmpty:
+		return nil
+	case errors.Is(err, content.ErrContentNotFound):
+		p = problem.New(http.StatusNotFound, fmt.Sprintf("No content found for concept with uuid %s", conceptUUID)).WithType(problem.TypeContentNotFound)
+		logEntry.Debugf(p.Detail)
+	default:
+		p = problem.New(http.StatusServiceUnavailable, fmt.Sprintf("Backend error returning content for concept with uuid %s", conceptUUID))
+		logEntry.WithError(err).Error(p.Detail)
+	}
+	return &p
+}
+
+// redirectToCanonical redirects requests for a concept concorded into another canonical concept to the location
+// of the canonical concept when the handler is configured to. The canonical concept is looked up before the content
+// so that redirected requests do not query it. Unknown concepts are left for the content query to report.
+// It reports whether it responded.
+func (h *Handler) redirectToCanonical(ctx context.Context, w http.ResponseWriter, conceptUUID string, location func(canonicalUUID string) string, logEntry *logger.LogEntry) bool {
+	if !h.RedirectToCanonical {
+		return false
+	}
+
+	dbStart := time.Now()
+	canonicalUUID, err := h.ContentService.CanonicalUUID(ctx, conceptUUID)
+	recordTiming(ctx, timingDB, dbStart)
+	if errors.Is(err, content.ErrConceptNotFound) {
+		return false
+	}
+	if err != nil {
+		msg := fmt.Sprintf("Backend error resolving concept with uuid %s", conceptUUID)
+		logEntry.WithError(err).Error(msg)
+		writeProblem(ctx, w, http.StatusServiceUnavailable, "", msg)
+		return true
+	}
+	if strings.EqualFold(canonicalUUID, conceptUUID) {
+		return false
+	}
+
+	w.Header().Set("Location", location(canonicalUUID))
+	w.Header().Set("Cache-Control", h.CacheControlHeader)
+	w.Header().Set(canonicalUUIDHeader, canonicalUUID)
+	w.Header().Set(resolvedFromHeader, conceptUUID)
+	writeServerTiming(ctx, w)
+	w.WriteHeader(http.StatusMovedPermanently)
+	return true
+}
+
+type clientQueryKey struct{}
 
+// keepClientQuery is a mux middleware keeping the query string the client sent, before the policy adds its
+// publication filter to it.
+func keepClientQuery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), clientQueryKey{}, r.URL.RawQuery)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// clientQuery returns the query string the client sent, without the parameters added by the policy.
+func clientQuery(r *http.Request) string {
+	if query, ok := r.Context().Value(clientQueryKey{}).(string); ok {
+		return query
+	}
+	return r.URL.RawQuery
+}
+
+// resolved reports whether the requested concept was resolved to a different canonical concept,
+// i.e. it was concorded into another one.
+func resolved(result content.ConceptContent, conceptUUID string) bool {
+	return result.CanonicalUUID != "" && !strings.EqualFold(result.CanonicalUUID, conceptUUID)
+}
+
+// writeProfiledContent responds with the content along with the query profile.
+// Unknown concepts and concepts without content are not reported as not found so that the profile is still returned.
+func (h *Handler) writeProfiledContent(ctx context.Context, w http.ResponseWriter, result content.ConceptContent, profile *content.QueryProfile, err error, opts responseOptions, conceptUUID string, logEntry *logger.LogEntry) {
+	if err != nil && !errors.Is(err, content.ErrContentNotFound) && !errors.Is(err, content.ErrConceptNotFound) {
 		msg := fmt.Sprintf("Backend error returning content for concept with uuid %s", conceptUUID)
 		logEntry.WithError(err).Error(msg)
-		writeJSONMessage(w, http.StatusServiceUnavailable, msg)
+		writeProblem(ctx, w, http.StatusServiceUnavailable, "", msg)
 		return
 	}
 
-	w.Header().Set("Cache-Control", h.CacheControlHeader)
-	w.WriteHeader(http.StatusOK)
+	if result.Content == nil {
+		result.Content = []content.Content{}
+	}
 
-	if err = json.NewEncoder(w).Encode(contentList); err != nil {
+	body := profiledContent{Content: result.Content, Profile: profile}
+	if opts.includeConcept {
+		body.Concept = result.Concept
+	}
+	h.writeContent(ctx, w, result, body, "no-store", conceptUUID, logEntry)
+}
+
+// writeContent responds with body along with headers describing the content found for the concept.
+// The body is encoded before anything is written so that encoding errors can still be reported.
+func (h *Handler) writeContent(ctx context.Context, w http.ResponseWriter, result content.ConceptContent, body interface{}, cacheControl string, conceptUUID string, logEntry *logger.LogEntry) {
+	encoded, err := encodeJSON(ctx, body)
+	if err != nil {
 		msg := fmt.Sprintf("Error parsing returned content list for concept with uuid %s", conceptUUID)
 		logEntry.WithError(err).Error(msg)
-		writeJSONMessage(w, http.StatusInternalServerError, msg)
+		writeProblem(ctx, w, http.StatusInternalServerError, "", msg)
 		return
 	}
+
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set(resultCountHeader, strconv.Itoa(len(result.Content)))
+	if result.CanonicalUUID != "" {
+		w.Header().Set(canonicalUUIDHeader, result.CanonicalUUID)
+	}
+	if resolved(result, conceptUUID) {
+		w.Header().Set(resolvedFromHeader, conceptUUID)
+	}
+	writeServerTiming(ctx, w)
+	w.WriteHeader(http.StatusOK)
+
+	if _, err = w.Write(encoded); err != nil {
+		logEntry.WithError(err).Errorf("Error writing content list for concept with uuid %s", conceptUUID)
+	}
+}
+
+// conceptRef is the concept isAnnotatedBy refers to, either by UUID or by the identifier an authority gave it.
+type conceptRef struct {
+	uuid       string
+	authority  string
+	identifier string
 }
 
-func extractRequestParams(val url.Values, log *logger.LogEntry) (content.RequestParams, error) {
+// parseConceptRef accepts the UUID of a concept, any of its FT URIs or an authority:identifier pair.
+func parseConceptRef(conceptURI string) (conceptRef, error) {
+	if matches := ftURIRegex.FindStringSubmatch(conceptURI); matches != nil {
+		return conceptRef{uuid: matches[1]}, nil
+	}
+
+	if authority, identifier, found := strings.Cut(conceptURI, ":"); found && !strings.Contains(conceptURI, "://") {
+		authorities := content.Authorities()
+		if !slices.ContainsFunc(authorities, func(a string) bool { return strings.EqualFold(a, authority) }) {
+			return conceptRef{}, newParamError("isAnnotatedBy", "%s is not a supported authority. Expecting one of: %s", authority, strings.Join(authorities, ", "))
+		}
+		if identifier == "" {
+			return conceptRef{}, newParamError("isAnnotatedBy", "Missing identifier for authority %s", authority)
+		}
+		return conceptRef{authority: authority, identifier: identifier}, nil
+	}
+
+	// anything else must be a bare UUID, not a URI of something other than a concept
+	conceptUUID := strings.TrimPrefix(conceptURI, thingURIPrefix)
+	if conceptUUID == "" || UUIDRegex.FindString(conceptUUID) != conceptUUID {
+		return conceptRef{}, newParamError("isAnnotatedBy", "%s extracted from request URL was not valid uuid", conceptUUID)
+	}
+	return conceptRef{uuid: conceptUUID}, nil
+}
+
+// resolveConceptRef returns the UUID of the concept, looking up the ones referred to by an authority identifier.
+func (h *Handler) resolveConceptRef(ctx context.Context, ref conceptRef) (string, error) {
+	if ref.uuid != "" {
+		return ref.uuid, nil
+	}
+	return h.ContentService.ConceptUUIDForIdentifier(ctx, ref.authority, ref.identifier)
+}
+
+// strictRequested reports whether the request is validated strictly, either because strict validation is enabled
+// for all requests or because the request asks for it with strict=true. Strict requests must only use the valid parameters.
+func (h *Handler) strictRequested(val url.Values, valid []string) (bool, error) {
+	requested, err := boolParam(val, "strict")
+	if err != nil {
+		return false, err
+	}
+	if !h.StrictValidation && !requested {
+		return false, nil
+	}
+
+	var unknown []string
+	for name := range val {
+		if !slices.Contains(valid, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		slices.Sort(unknown)
+		return true, newParamError(unknown[0], "unknown query parameters: %s. Valid parameters are: %s", strings.Join(unknown, ", "), strings.Join(valid, ", "))
+	}
+	return true, nil
+}
+
+func (h *Handler) extractRequestParams(val url.Values, strict bool, log *logger.LogEntry) (content.RequestParams, error) {
 	var (
-		page          = defaultPage
-		contentLimit  = defaultLimit
-		fromDateEpoch = int64(0)
-		toDateEpoch   = int64(0)
-		publication   []string
-		err           error
+		page         = defaultPage
+		contentLimit = defaultLimit
+		publication  []string
+		err          error
 	)
 
 	pageParam := val.Get("page")
@@ -157,13 +566,17 @@ func extractRequestParams(val url.Values, log *logger.LogEntry) (content.Request
 		if err != nil {
 			msg := fmt.Sprintf("provided value for page, %s, could not be parsed.", pageParam)
 			log.WithError(err).Error(msg)
-			return content.RequestParams{}, errors.New(msg)
+			return content.RequestParams{}, &paramError{param: "page", msg: msg}
 		}
 
 		if page < defaultPage {
 			msg := fmt.Sprintf("provided value for page should be greater than: %v", defaultPage)
 			log.Debugf(msg)
-			return content.RequestParams{}, errors.New(msg)
+			return content.RequestParams{}, &paramError{param: "page", msg: msg}
+		}
+
+		if strict && h.MaxPageDepth > 0 && page > h.MaxPageDepth {
+			return content.RequestParams{}, newParamError("page", "provided value for page should not be greater than: %d", h.MaxPageDepth)
 		}
 	}
 
@@ -173,15 +586,67 @@ func extractRequestParams(val url.Values, log *logger.LogEntry) (content.Request
 		log.Debugf("No contentLimit provided. Using default: %d", defaultLimit)
 	} else {
 		limit, err := strconv.Atoi(limitParam)
-		if err != nil {
+		switch {
+		case err != nil && strict:
+			return content.RequestParams{}, newParamError("limit", "provided value for limit, %s, could not be parsed.", limitParam)
+		case err != nil:
 			log.Debugf("provided value for contentLimit, %s, could not be parsed. Using default: %d", limitParam, defaultLimit)
-		} else if limit < 0 {
+		case limit < 0 && strict:
+			return content.RequestParams{}, newParamError("limit", "provided value for limit should not be negative")
+		case limit < 0:
 			contentLimit = 0
-		} else {
+		case strict && h.MaxLimit > 0 && limit > h.MaxLimit:
+			return content.RequestParams{}, newParamError("limit", "provided value for limit should not be greater than: %d", h.MaxLimit)
+		default:
 			contentLimit = limit
 		}
 	}
 
+	fromDateEpoch, toDateEpoch, _, err := extractDates(val, strict, log)
+	if err != nil {
+		return content.RequestParams{}, err
+	}
+
+	publication, err = extractPublication(val, log)
+	if err != nil {
+		return content.RequestParams{}, err
+	}
+
+	sort, err := extractSort(val)
+	if err != nil {
+		return content.RequestParams{}, err
+	}
+
+	maxPerGroup, groupBy, err := extractGrouping(val)
+	if err != nil {
+		return content.RequestParams{}, err
+	}
+	if maxPerGroup > 0 && contentLimit > 0 && page > content.MaxGroupedContent/contentLimit {
+		return content.RequestParams{}, newParamError("page", "page and limit should not go past the first %d pieces of content with maxPerGroup", content.MaxGroupedContent)
+	}
+
+	includeAnnotations, err := boolParam(val, "includeAnnotations")
+	if err != nil {
+		return content.RequestParams{}, err
+	}
+
+	return content.RequestParams{
+		Page:               page,
+		ContentLimit:       contentLimit,
+		FromDateEpoch:      fromDateEpoch,
+		ToDateEpoch:        toDateEpoch,
+		Publication:        publication,
+		Sort:               sort,
+		MaxPerGroup:        maxPerGroup,
+		GroupBy:            groupBy,
+		IncludeAnnotations: includeAnnotations,
+	}, nil
+}
+
+// extractDates returns the epochs of fromDate and toDate, which are optional, and whether both were given.
+// Either can be the epoch itself. Strict requests must give fromDate before toDate.
+func extractDates(val url.Values, strict bool, log *logger.LogEntry) (int64, int64, bool, error) {
+	var fromDateEpoch, toDateEpoch int64
 	fromDateParam := val.Get("fromDate")
 	toDateParam := val.Get("toDate")
 
@@ -192,7 +657,7 @@ func extractRequestParams(val url.Values, log *logger.LogEntry) (content.Request
 		if err != nil {
 			msg := fmt.Sprintf("From date value %s could not be parsed", fromDateParam)
 			log.WithError(err).Error(msg)
-			return content.RequestParams{}, errors.New(msg)
+			return 0, 0, false, &paramError{param: "fromDate", msg: msg}
 		}
 		fromDateEpoch = fromDateTime.Unix()
 	}
@@ -204,39 +669,159 @@ func extractRequestParams(val url.Values, log *logger.LogEntry) (content.Request
 		if err != nil {
 			msg := fmt.Sprintf("To date value %s could not be parsed", toDateParam)
 			log.WithError(err).Error(msg)
-			return content.RequestParams{}, errors.New(msg)
+			return 0, 0, false, &paramError{param: "toDate", msg: msg}
 		}
 		toDateEpoch = toDateTime.Unix()
 	}
 
-	publicationParam := val["publication"]
+	dated := fromDateParam != "" && toDateParam != ""
+	if strict && dated && fromDateEpoch >= toDateEpoch {
+		return 0, 0, false, newParamError("fromDate", "From date %s should be before to date %s", fromDateParam, toDateParam)
+	}
+	return fromDateEpoch, toDateEpoch, dated, nil
+}
 
+// extractGrouping returns the cap on the content of each group in a page and what the content is grouped by,
+// which go together.
+func extractGrouping(val url.Values) (int, content.GroupBy, error) {
+	maxPerGroup, err := intParam(val, "maxPerGroup", 0, 1, 0)
+	if err != nil {
+		return 0, "", err
+	}
+
+	groupBy := content.GroupBy(val.Get("groupBy"))
+	groupBys := make([]string, 0, len(content.GroupBys()))
+	for _, g := range content.GroupBys() {
+		groupBys = append(groupBys, string(g))
+	}
+	switch {
+	case maxPerGroup == 0 && groupBy == "":
+		return 0, "", nil
+	case groupBy == "":
+		return 0, "", newParamError("groupBy", "Missing or empty query parameter groupBy, required with maxPerGroup. Expecting one of: %s", strings.Join(groupBys, ", "))
+	case !slices.Contains(content.GroupBys(), groupBy):
+		return 0, "", newParamError("groupBy", "%s is not a supported groupBy. Expecting one of: %s", groupBy, strings.Join(groupBys, ", "))
+	case maxPerGroup == 0:
+		return 0, "", newParamError("maxPerGroup", "Missing or empty query parameter maxPerGroup, required with groupBy.")
+	}
+	return maxPerGroup, groupBy, nil
+}
+
+// extractSort returns the order asked for, newest first by default. Unknown orders are always rejected
+// as falling back to date order would silently break timelines.
+func extractSort(val url.Values) (content.Sort, error) {
+	sort := content.Sort(val.Get("sort"))
+	if sort == "" {
+		return content.SortNewest, nil
+	}
+	if !slices.Contains(content.Sorts(), sort) {
+		sorts := make([]string, 0, len(content.Sorts()))
+		for _, s := range content.Sorts() {
+			sorts = append(sorts, string(s))
+		}
+		return "", newParamError("sort", "%s is not a supported sort. Expecting one of: %s", sort, strings.Join(sorts, ", "))
+	}
+	return sort, nil
+}
+
+// extractPublication returns the publications asked for, either repeated or as a comma separated list.
+func extractPublication(val url.Values, log *logger.LogEntry) ([]string, error) {
+	publicationParam := val["publication"]
 	if len(publicationParam) == 0 {
 		log.Debug("no publication url param supplied")
-	} else {
-		for _, pubParam := range publicationParam {
-			publication = append(publication, strings.Split(pubParam, ",")...)
-		}
+		return nil, nil
+	}
 
-		for _, pub := range publication {
-			if !UUIDRegex.MatchString(pub) {
-				msg := fmt.Sprintf("Publication array param contains value %s which is not valid uuid", pub)
-				log.WithError(err).Error(msg)
-				return content.RequestParams{}, errors.New(msg)
-			}
+	var publication []string
+	for _, pubParam := range publicationParam {
+		publication = append(publication, strings.Split(pubParam, ",")...)
+	}
+
+	for _, pub := range publication {
+		if !UUIDRegex.MatchString(pub) {
+			msg := fmt.Sprintf("Publication array param contains value %s which is not valid uuid", pub)
+			log.Error(msg)
+			return nil, &paramError{param: "publication", msg: msg}
 		}
 	}
+	return publication, nil
+}
 
-	return content.RequestParams{
-		Page:          page,
-		ContentLimit:  contentLimit,
-		FromDateEpoch: fromDateEpoch,
-		ToDateEpoch:   toDateEpoch,
-		Publication:   publication,
-	}, nil
+// encodeJSON encodes v in a span of its own so that the time spent encoding shows up in traces and Server-Timing.
+func encodeJSON(ctx context.Context, v interface{}) ([]byte, error) {
+	_, span := tracer.Start(ctx, "encode response")
+	defer span.End()
+	defer recordTiming(ctx, timingEncode, time.Now())
+
+	var buf bytes.Buffer
+	err := json.NewEncoder(&buf).Encode(v)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return buf.Bytes(), err
+}
+
+// paramError is a request parameter value that cannot be accepted.
+type paramError struct {
+	param string
+	msg   string
+}
+
+func newParamError(param, format string, args ...interface{}) *paramError {
+	return &paramError{param: param, msg: fmt.Sprintf(format, args...)}
+}
+
+func (e *paramError) Error() string {
+	return e.msg
+}
+
+// writeRequestError responds to a request that cannot be served as asked.
+func writeRequestError(ctx context.Context, w http.ResponseWriter, err error) {
+	writeDetails(ctx, w, requestProblem(err))
+}
+
+// requestProblem describes why the request cannot be accepted.
+func requestProblem(err error) problem.Details {
+	if errors.Is(err, errDebugForbidden) {
+		return problem.New(http.StatusForbidden, err.Error()).WithParam("debug")
+	}
+	if errors.Is(err, errPublicationForbidden) {
+		return problem.New(http.StatusForbidden, err.Error()).WithParam("publication")
+	}
+
+	var pErr *paramError
+	if errors.As(err, &pErr) {
+		return problem.New(http.StatusBadRequest, pErr.msg).WithParam(pErr.param)
+	}
+	return problem.New(http.StatusBadRequest, err.Error())
+}
+
+// writeProblem responds with problem details for the request in ctx. param names the offending request parameter, if any.
+func writeProblem(ctx context.Context, w http.ResponseWriter, status int, param, detail string) {
+	writeDetails(ctx, w, problem.New(status, detail).WithParam(param))
+}
+
+// writeConceptNotFound responds that the concept of the request does not exist.
+func writeConceptNotFound(ctx context.Context, w http.ResponseWriter, detail string) {
+	writeDetails(ctx, w, problem.New(http.StatusNotFound, detail).WithType(problem.TypeConceptNotFound))
+}
+
+// routeNotFound answers requests for paths the router has no route for.
+func routeNotFound(w http.ResponseWriter, r *http.Request) {
+	problem.Write(w, transactionidutils.GetTransactionIDFromRequest(r),
+		problem.New(http.StatusNotFound, fmt.Sprintf("No endpoint found at %s", r.URL.Path)))
+}
+
+// methodNotAllowed answers requests for a path the router has routes for, but not with the method of the request.
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	problem.Write(w, transactionidutils.GetTransactionIDFromRequest(r),
+		problem.New(http.StatusMethodNotAllowed, fmt.Sprintf("Method %s is not allowed at %s", r.Method, r.URL.Path)))
 }
 
-func writeJSONMessage(w http.ResponseWriter, status int, msg string) {
-	w.WriteHeader(status)
-	_, _ = w.Write([]byte(`{"message": "` + msg + `"}`))
+// writeDetails responds with the problem details for the request in ctx.
+func writeDetails(ctx context.Context, w http.ResponseWriter, details problem.Details) {
+	transID, _ := transactionidutils.GetTransactionIDFromContext(ctx)
+	writeServerTiming(ctx, w)
+	problem.Write(w, transID, details)
 }
@@ -1,25 +1,37 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 
 	"github.com/Financial-Times/go-logger/v2"
 	"github.com/Financial-Times/public-content-by-concept-api/v2/content"
 	"github.com/Financial-Times/public-content-by-concept-api/v2/policy"
+	"github.com/Financial-Times/public-content-by-concept-api/v2/problem"
 	"github.com/stretchr/testify/assert"
 )
 
 const (
-	testConceptID    = "44129750-7616-11e8-b45a-da24cd01f044"
-	testContentUUID  = "e89db5e2-760d-11e8-b45a-da24cd01f044"
-	anotherConceptID = "347e2eca-7860-11e8-b45a-da24cd01f044"
+	testConceptID       = "44129750-7616-11e8-b45a-da24cd01f044"
+	testContentUUID     = "e89db5e2-760d-11e8-b45a-da24cd01f044"
+	anotherConceptID    = "347e2eca-7860-11e8-b45a-da24cd01f044"
+	subsidiaryConceptID = "5a4b8c1e-7861-11e8-b45a-da24cd01f044"
+
+	testTransactionID = "tid_test"
+	testTMEIdentifier = "N11dGE8juUH-T04="
+	// testAmbiguousTMEIdentifier identifies more than one concept
+	testAmbiguousTMEIdentifier = "MwNGJhM2Vi-T04="
 )
 
 var (
@@ -80,6 +92,7 @@ func TestContentByConceptHandler_GetContentByConcept(t *testing.T) {
 			contentLimit:       "10",
 			publication:        []string{"88fdde6c-2aa4-4f78-af02-9f680097cfd6", "8e6c705e-1132-42a2-8db0-c295e29e8658"},
 			expectedStatusCode: http.StatusForbidden,
+			expectedBody:       problemBody(http.StatusForbidden, "", "Request is forbidden due to missing or non-matching access policies"),
 			opaPolicyResult:    isNotAuthorized,
 		},
 		{
@@ -113,7 +126,7 @@ func TestContentByConceptHandler_GetContentByConcept(t *testing.T) {
 			conceptID:          "",
 			contentList:        []string{testContentUUID},
 			expectedStatusCode: http.StatusBadRequest,
-			expectedBody:       `{"message": "Missing or empty query parameter isAnnotatedBy. Expecting valid absolute concept URI."}`,
+			expectedBody:       problemBody(http.StatusBadRequest, "isAnnotatedBy", "Missing or empty query parameter isAnnotatedBy. Expecting valid absolute concept URI."),
 			opaPolicyResult:    isAuthorized,
 		},
 		{
@@ -129,7 +142,7 @@ func TestContentByConceptHandler_GetContentByConcept(t *testing.T) {
 			conceptID:          "NullURI",
 			contentList:        []string{testContentUUID},
 			expectedStatusCode: http.StatusBadRequest,
-			expectedBody:       `{"message": "Missing or empty query parameter isAnnotatedBy. Expecting valid absolute concept URI."}`,
+			expectedBody:       problemBody(http.StatusBadRequest, "isAnnotatedBy", "Missing or empty query parameter isAnnotatedBy. Expecting valid absolute concept URI."),
 			opaPolicyResult:    isAuthorized,
 		},
 		{
@@ -137,7 +150,7 @@ func TestContentByConceptHandler_GetContentByConcept(t *testing.T) {
 			conceptID:          "123456",
 			contentList:        []string{testContentUUID},
 			expectedStatusCode: http.StatusBadRequest,
-			expectedBody:       `{"message": "123456 extracted from request URL was not valid uuid"}`,
+			expectedBody:       problemBody(http.StatusBadRequest, "isAnnotatedBy", "123456 extracted from request URL was not valid uuid"),
 			opaPolicyResult:    isAuthorized,
 		},
 		{
@@ -146,7 +159,7 @@ func TestContentByConceptHandler_GetContentByConcept(t *testing.T) {
 			contentList:        []string{testContentUUID},
 			page:               "null",
 			expectedStatusCode: http.StatusBadRequest,
-			expectedBody:       `{"message": "provided value for page, null, could not be parsed."}`,
+			expectedBody:       problemBody(http.StatusBadRequest, "page", "provided value for page, null, could not be parsed."),
 			opaPolicyResult:    isAuthorized,
 		},
 		{
@@ -172,7 +185,7 @@ func TestContentByConceptHandler_GetContentByConcept(t *testing.T) {
 			contentList:        []string{testContentUUID},
 			fromDate:           "null",
 			expectedStatusCode: http.StatusBadRequest,
-			expectedBody:       `{"message": "From date value null could not be parsed"}`,
+			expectedBody:       problemBody(http.StatusBadRequest, "fromDate", "From date value null could not be parsed"),
 			opaPolicyResult:    isAuthorized,
 		},
 		{
@@ -181,7 +194,7 @@ func TestContentByConceptHandler_GetContentByConcept(t *testing.T) {
 			contentList:        []string{testContentUUID},
 			toDate:             "null",
 			expectedStatusCode: http.StatusBadRequest,
-			expectedBody:       `{"message": "To date value null could not be parsed"}`,
+			expectedBody:       problemBody(http.StatusBadRequest, "toDate", "To date value null could not be parsed"),
 			opaPolicyResult:    isAuthorized,
 		},
 		{
@@ -189,7 +202,7 @@ func TestContentByConceptHandler_GetContentByConcept(t *testing.T) {
 			conceptID:          testConceptID,
 			contentList:        []string{testContentUUID},
 			expectedStatusCode: http.StatusServiceUnavailable,
-			expectedBody:       `{"message": "Backend error returning content for concept with uuid 44129750-7616-11e8-b45a-da24cd01f044"}`,
+			expectedBody:       problemBody(http.StatusServiceUnavailable, "", "Backend error returning content for concept with uuid 44129750-7616-11e8-b45a-da24cd01f044"),
 			backendError:       errors.New("there was a problem"),
 			opaPolicyResult:    isAuthorized,
 		},
@@ -197,7 +210,7 @@ func TestContentByConceptHandler_GetContentByConcept(t *testing.T) {
 			testName:           "No content for concept returns 404",
 			conceptID:          testConceptID,
 			expectedStatusCode: http.StatusNotFound,
-			expectedBody:       `{"message": "No content found for concept with uuid 44129750-7616-11e8-b45a-da24cd01f044"}`,
+			expectedBody:       notFoundBody(problem.TypeContentNotFound, "No content found for concept with uuid 44129750-7616-11e8-b45a-da24cd01f044"),
 			opaPolicyResult:    isAuthorized,
 		},
 		{
@@ -206,7 +219,7 @@ func TestContentByConceptHandler_GetContentByConcept(t *testing.T) {
 			contentList:        []string{testContentUUID},
 			publication:        []string{"88fdde6c-2aa4-4f78-af02-9f680097cfd"},
 			expectedStatusCode: http.StatusBadRequest,
-			expectedBody:       `{"message": "Publication array param contains value 88fdde6c-2aa4-4f78-af02-9f680097cfd which is not valid uuid"}`,
+			expectedBody:       problemBody(http.StatusBadRequest, "publication", "Publication array param contains value 88fdde6c-2aa4-4f78-af02-9f680097cfd which is not valid uuid"),
 			opaPolicyResult:    isAuthorized,
 		},
 	}
@@ -263,21 +276,27 @@ func TestContentByConceptHandler_GetContentByConceptImplicitly(t *testing.T) {
 			conceptID:          "NullURI",
 			contentList:        []string{testContentUUID},
 			expectedStatusCode: http.StatusBadRequest,
-			expectedBody:       `{"message": "NullURI extracted from request URL was not valid uuid"}`,
+			expectedBody:       problemBody(http.StatusBadRequest, "conceptUUID", "NullURI extracted from request URL was not valid uuid"),
+		},
+		{
+			testName:           "Bad Request: conceptUUID with quotes is safely encoded",
+			conceptID:          "abc%22%7D",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "conceptUUID", `abc"} extracted from request URL was not valid uuid`),
 		},
 		{
 			testName:           "Backend Error returns 503",
 			conceptID:          testConceptID,
 			contentList:        []string{testContentUUID},
 			expectedStatusCode: http.StatusServiceUnavailable,
-			expectedBody:       `{"message": "Backend error returning content for concept with uuid 44129750-7616-11e8-b45a-da24cd01f044"}`,
+			expectedBody:       problemBody(http.StatusServiceUnavailable, "", "Backend error returning content for concept with uuid 44129750-7616-11e8-b45a-da24cd01f044"),
 			backendError:       errors.New("there was a problem"),
 		},
 		{
 			testName:           "No content for concept returns 404",
 			conceptID:          testConceptID,
 			expectedStatusCode: http.StatusNotFound,
-			expectedBody:       `{"message": "No content found for concept with uuid 44129750-7616-11e8-b45a-da24cd01f044"}`,
+			expectedBody:       notFoundBody(problem.TypeContentNotFound, "No content found for concept with uuid 44129750-7616-11e8-b45a-da24cd01f044"),
 		},
 	}
 
@@ -290,81 +309,1826 @@ func TestContentByConceptHandler_GetContentByConceptImplicitly(t *testing.T) {
 		r.HandleFunc("/content/{conceptUUID}/implicitly", handler.GetContentByConceptImplicitly).Methods("GET")
 		r.ServeHTTP(rec, newRequest("GET", fmt.Sprintf("/content/%s/implicitly", test.conceptID)))
 		assert.Equal(test.expectedStatusCode, rec.Code, "There was an error returning the correct status code")
+		if test.expectedStatusCode != http.StatusOK {
+			assert.Equal(problem.ContentType, rec.Header().Get("Content-Type"), "Errors should be problem details")
+		}
 		if test.expectedBody != "" {
 			assert.Equal(test.expectedBody, rec.Body.String(), "Wrong body")
 		}
 	}
 }
 
-func buildURL(conceptID, fromDate, toDate, page, contentLimit string, publication []string) string {
-	var URL = fmt.Sprintf("/content?isAnnotatedBy=http://api.ft.com/things/%s", conceptID)
-	if fromDate != "" {
-		URL = URL + fmt.Sprintf("&fromDate=%s", fromDate)
-	}
-	if toDate != "" {
-		URL = URL + fmt.Sprintf("&toDate=%s", toDate)
+func TestContentByConceptHandler_GetContentByConceptImplicitlyWithSubsidiaries(t *testing.T) {
+	log := logger.NewUPPLogger("test-service", "info")
+
+	tests := []struct {
+		testName           string
+		query              string
+		maxSubsidiaryDepth int
+		expectedStatusCode int
+		expectedBody       string
+		expectedParams     content.ImplicitParams
+	}{
+		{
+			testName:           "Subsidiaries are left out by default",
+			maxSubsidiaryDepth: 3,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			testName:           "Subsidiaries down to the depth asked for",
+			query:              "?subsidiaryDepth=2",
+			maxSubsidiaryDepth: 3,
+			expectedStatusCode: http.StatusOK,
+			expectedParams:     content.ImplicitParams{SubsidiaryDepth: 2},
+		},
+		{
+			testName:           "Annotations asked for along with subsidiaries",
+			query:              "?subsidiaryDepth=1&includeAnnotations=true",
+			maxSubsidiaryDepth: 3,
+			expectedStatusCode: http.StatusOK,
+			expectedParams:     content.ImplicitParams{SubsidiaryDepth: 1, IncludeAnnotations: true},
+		},
+		{
+			testName:           "Depth over the maximum",
+			query:              "?subsidiaryDepth=4",
+			maxSubsidiaryDepth: 3,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "subsidiaryDepth", "provided value for subsidiaryDepth should not be greater than: 3"),
+		},
+		{
+			testName:           "Subsidiaries disabled",
+			query:              "?subsidiaryDepth=1",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "subsidiaryDepth", "provided value for subsidiaryDepth should not be greater than: 0"),
+		},
+		{
+			testName:           "Negative depth",
+			query:              "?subsidiaryDepth=-1",
+			maxSubsidiaryDepth: 3,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "subsidiaryDepth", "provided value for subsidiaryDepth should not be less than: 0"),
+		},
+		{
+			testName:           "Depth that is not a number",
+			query:              "?subsidiaryDepth=all",
+			maxSubsidiaryDepth: 3,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "subsidiaryDepth", "provided value for subsidiaryDepth, all, could not be parsed."),
+		},
 	}
-	if contentLimit != "" {
-		URL = URL + fmt.Sprintf("&limit=%s", contentLimit)
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			var params content.ImplicitParams
+			rs := recordingService{dummyService: dummyService{[]string{testContentUUID}, nil}, implicitParams: &params}
+			handler := Handler{ContentService: rs, CacheControlHeader: "10", Log: log, MaxSubsidiaryDepth: test.maxSubsidiaryDepth}
+
+			rec := httptest.NewRecorder()
+			r := mux.NewRouter()
+			r.HandleFunc("/content/{conceptUUID}/implicitly", handler.GetContentByConceptImplicitly).Methods("GET")
+			r.ServeHTTP(rec, newRequest("GET", "/content/"+testConceptID+"/implicitly"+test.query))
+
+			assert.Equal(t, test.expectedStatusCode, rec.Code)
+			if test.expectedBody != "" {
+				assert.JSONEq(t, test.expectedBody, rec.Body.String())
+			}
+			assert.Equal(t, test.expectedParams, params)
+		})
 	}
-	if page != "" {
-		URL = URL + fmt.Sprintf("&page=%s", page)
+}
+
+func TestContentByConceptHandler_DebugProfile(t *testing.T) {
+	log := logger.NewUPPLogger("test-service", "info")
+
+	tests := []struct {
+		testName           string
+		url                string
+		debugEnabled       bool
+		accessFrom         string
+		contentList        []string
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			testName:           "Profile is returned for admin request",
+			url:                "/content?isAnnotatedBy=" + testConceptID + "&debug=profile",
+			debugEnabled:       true,
+			contentList:        []string{testContentUUID},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"content":[{"id":"http://www.ft.com/content/e89db5e2-760d-11e8-b45a-da24cd01f044","apiUrl":"http://api.ft.com/content/e89db5e2-760d-11e8-b45a-da24cd01f044"}],"profile":{"cypher":"MATCH (c:Content)","parameters":null,"dbHits":42,"rows":1,"timings":{"resultAvailableAfterMs":0,"resultConsumedAfterMs":0,"totalMs":0}}}` + "\n",
+		},
+		{
+			testName:           "Profile is returned for implicit admin request without content",
+			url:                "/content/" + testConceptID + "/implicitly?debug=profile",
+			debugEnabled:       true,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"content":[],"profile":{"cypher":"MATCH (c:Content)","parameters":null,"dbHits":42,"rows":1,"timings":{"resultAvailableAfterMs":0,"resultConsumedAfterMs":0,"totalMs":0}}}` + "\n",
+		},
+		{
+			testName:           "Forbidden when debug is disabled",
+			url:                "/content?isAnnotatedBy=" + testConceptID + "&debug=profile",
+			contentList:        []string{testContentUUID},
+			expectedStatusCode: http.StatusForbidden,
+			expectedBody:       problemBody(http.StatusForbidden, "debug", "debug mode is only available to admin requests"),
+		},
+		{
+			testName:           "Forbidden for requests through the API Gateway",
+			url:                "/content/" + testConceptID + "/implicitly?debug=profile",
+			debugEnabled:       true,
+			accessFrom:         "API Gateway",
+			contentList:        []string{testContentUUID},
+			expectedStatusCode: http.StatusForbidden,
+			expectedBody:       problemBody(http.StatusForbidden, "debug", "debug mode is only available to admin requests"),
+		},
+		{
+			testName:           "Bad Request: unsupported debug value",
+			url:                "/content?isAnnotatedBy=" + testConceptID + "&debug=explain",
+			debugEnabled:       true,
+			contentList:        []string{testContentUUID},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "debug", "provided value for debug, explain, is not supported. Expecting profile"),
+		},
 	}
-	if len(publication) != 0 {
-		URL = URL + fmt.Sprintf("&publication=%s", strings.Join(publication, ","))
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			ds := dummyService{test.contentList, nil}
+			handler := Handler{ContentService: &ds, CacheControlHeader: "10", Log: log, QueryDebugEnabled: test.debugEnabled}
+
+			rec := httptest.NewRecorder()
+			r := mux.NewRouter()
+			r.HandleFunc("/content", handler.GetContentByConcept).Methods("GET")
+			r.HandleFunc("/content/{conceptUUID}/implicitly", handler.GetContentByConceptImplicitly).Methods("GET")
+
+			req := newRequest("GET", test.url)
+			if test.accessFrom != "" {
+				req.Header.Set("Access-From", test.accessFrom)
+			}
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, test.expectedStatusCode, rec.Code, "There was an error returning the correct status code")
+			assert.Equal(t, test.expectedBody, rec.Body.String(), "Wrong body")
+		})
 	}
-	return URL
 }
 
-func newRequest(method, url string) *http.Request {
-	req, err := http.NewRequest(method, url, nil)
-	if err != nil {
-		panic(err)
+func TestContentByConceptHandler_DiagnosticHeaders(t *testing.T) {
+	log := logger.NewUPPLogger("test-service", "info")
+
+	tests := []struct {
+		testName             string
+		url                  string
+		serverTiming         bool
+		redirectToCanonical  bool
+		expectedServerTiming []string
+	}{
+		{
+			testName: "Headers without Server-Timing",
+			url:      "/content?isAnnotatedBy=" + testConceptID,
+		},
+		{
+			testName:             "Headers with Server-Timing",
+			url:                  "/content?isAnnotatedBy=" + testConceptID,
+			serverTiming:         true,
+			expectedServerTiming: []string{"db;dur=", "encode;dur="},
+		},
+		{
+			testName:             "Headers for implicit content with Server-Timing",
+			url:                  "/content/" + testConceptID + "/implicitly",
+			serverTiming:         true,
+			expectedServerTiming: []string{"db;dur=", "encode;dur="},
+		},
+		{
+			testName:             "Canonical concept lookup and content query timed together",
+			url:                  "/content?isAnnotatedBy=" + testConceptID,
+			serverTiming:         true,
+			redirectToCanonical:  true,
+			expectedServerTiming: []string{"db;dur=", "encode;dur="},
+		},
 	}
-	return req
-}
 
-type dummyService struct {
-	contentIDList []string
-	backendErr    error
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			ds := dummyService{[]string{testContentUUID, anotherConceptID}, nil}
+			handler := Handler{ContentService: &ds, CacheControlHeader: "10", Log: log, RedirectToCanonical: test.redirectToCanonical}
+
+			rec := httptest.NewRecorder()
+			r := mux.NewRouter()
+			if test.serverTiming {
+				r.Use(recordServerTiming)
+			}
+			r.HandleFunc("/content", handler.GetContentByConcept).Methods("GET")
+			r.HandleFunc("/content/{conceptUUID}/implicitly", handler.GetContentByConceptImplicitly).Methods("GET")
+			r.ServeHTTP(rec, newRequest("GET", test.url))
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "2", rec.Header().Get("X-Result-Count"))
+			assert.Equal(t, testConceptID, rec.Header().Get("X-Canonical-Concept-UUID"))
+
+			timing := rec.Header().Get("Server-Timing")
+			if len(test.expectedServerTiming) == 0 {
+				assert.Empty(t, timing)
+			}
+			for _, phase := range test.expectedServerTiming {
+				assert.Equal(t, 1, strings.Count(timing, phase), "Phase %s should be reported once in %s", phase, timing)
+			}
+		})
+	}
 }
 
-func (dS dummyService) GetContentForConcept(conceptUUID string, params content.RequestParams) ([]content.Content, error) {
-	if dS.backendErr != nil {
-		return nil, dS.backendErr
+func TestContentByConceptHandler_StrictValidation(t *testing.T) {
+	log := logger.NewUPPLogger("test-service", "info")
+	contentURL := "/content?isAnnotatedBy=" + testConceptID
+
+	tests := []struct {
+		testName           string
+		url                string
+		strictValidation   bool
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			testName:           "Lenient request falls back to defaults",
+			url:                contentURL + "&limit=abc&unknown=1",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			testName:           "Strict request with valid parameters",
+			url:                contentURL + "&limit=100&page=10&fromDate=2018-01-01&toDate=2018-06-20&strict=true",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			testName:           "Invalid strict parameter",
+			url:                contentURL + "&strict=yes",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "strict", "provided value for strict, yes, could not be parsed. Expecting true or false"),
+		},
+		{
+			testName:           "Unknown parameters in strict request",
+			url:                contentURL + "&strict=true&order=asc&foo=bar",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody: problemBody(http.StatusBadRequest, "foo", "unknown query parameters: foo, order. "+
+				"Valid parameters are: isAnnotatedBy, page, limit, fromDate, toDate, publication, sort, maxPerGroup, groupBy, allowEmpty, includeConcept, includeAnnotations, debug, strict"),
+		},
+		{
+			testName:           "Unparseable limit with strict validation enabled",
+			url:                contentURL + "&limit=abc",
+			strictValidation:   true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "limit", "provided value for limit, abc, could not be parsed."),
+		},
+		{
+			testName:           "Negative limit with strict validation enabled",
+			url:                contentURL + "&limit=-1",
+			strictValidation:   true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "limit", "provided value for limit should not be negative"),
+		},
+		{
+			testName:           "Limit over the cap",
+			url:                contentURL + "&limit=101&strict=true",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "limit", "provided value for limit should not be greater than: 100"),
+		},
+		{
+			testName:           "Page over the cap",
+			url:                contentURL + "&page=11&strict=true",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "page", "provided value for page should not be greater than: 10"),
+		},
+		{
+			testName:           "From date after to date",
+			url:                contentURL + "&fromDate=2018-06-20&toDate=2018-01-01&strict=true",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "fromDate", "From date 2018-06-20 should be before to date 2018-01-01"),
+		},
+		{
+			testName:           "Unknown parameters in strict implicit request",
+			url:                "/content/" + testConceptID + "/implicitly?limit=10",
+			strictValidation:   true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "limit", "unknown query parameters: limit. Valid parameters are: allowEmpty, includeConcept, includeAnnotations, subsidiaryDepth, debug, strict"),
+		},
 	}
-	if len(dS.contentIDList) == 0 && dS.backendErr == nil {
-		return nil, content.ErrContentNotFound
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			ds := dummyService{[]string{testContentUUID}, nil}
+			handler := Handler{ContentService: &ds, CacheControlHeader: "10", Log: log,
+				StrictValidation: test.strictValidation, MaxLimit: 100, MaxPageDepth: 10}
+
+			rec := httptest.NewRecorder()
+			r := mux.NewRouter()
+			r.HandleFunc("/content", handler.GetContentByConcept).Methods("GET")
+			r.HandleFunc("/content/{conceptUUID}/implicitly", handler.GetContentByConceptImplicitly).Methods("GET")
+			r.ServeHTTP(rec, newRequest("GET", test.url))
+
+			assert.Equal(t, test.expectedStatusCode, rec.Code)
+			if test.expectedBody != "" {
+				assert.Equal(t, test.expectedBody, rec.Body.String())
+			}
+		})
 	}
+}
 
-	cntList := make([]content.Content, 0)
-	for _, contentID := range dS.contentIDList {
-		var con = content.Content{}
-		con.APIURL = apiURL(contentID)
-		con.ID = idURL(contentID)
-		cntList = append(cntList, con)
+func TestContentByConceptHandler_Sort(t *testing.T) {
+	log := logger.NewUPPLogger("test-service", "info")
+	contentURL := "/content?isAnnotatedBy=" + testConceptID
+
+	tests := []struct {
+		testName           string
+		url                string
+		expectedStatusCode int
+		expectedBody       string
+		expectedSort       content.Sort
+	}{
+		{
+			testName:           "Newest first by default",
+			url:                contentURL,
+			expectedStatusCode: http.StatusOK,
+			expectedSort:       content.SortNewest,
+		},
+		{
+			testName:           "Oldest first",
+			url:                contentURL + "&sort=asc",
+			expectedStatusCode: http.StatusOK,
+			expectedSort:       content.SortOldest,
+		},
+		{
+			testName:           "Relevance",
+			url:                contentURL + "&sort=relevance&strict=true",
+			expectedStatusCode: http.StatusOK,
+			expectedSort:       content.SortRelevance,
+		},
+		{
+			testName:           "Unknown sort",
+			url:                contentURL + "&sort=popularity",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "sort", "popularity is not a supported sort. Expecting one of: desc, asc, relevance"),
+		},
 	}
 
-	return cntList, nil
-}
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			var params content.RequestParams
+			ds := recordingService{dummyService: dummyService{[]string{testContentUUID}, nil}, requestParams: &params}
+			handler := Handler{ContentService: &ds, CacheControlHeader: "10", Log: log}
 
-func (dS dummyService) GetContentForConceptImplicitly(conceptUUID string) ([]content.Content, error) {
-	if dS.backendErr != nil {
-		return nil, dS.backendErr
+			rec := httptest.NewRecorder()
+			r := mux.NewRouter()
+			r.HandleFunc("/content", handler.GetContentByConcept).Methods("GET")
+			r.ServeHTTP(rec, newRequest("GET", test.url))
+
+			assert.Equal(t, test.expectedStatusCode, rec.Code)
+			if test.expectedBody != "" {
+				assert.JSONEq(t, test.expectedBody, rec.Body.String())
+			}
+			assert.Equal(t, test.expectedSort, params.Sort)
+		})
 	}
-	if len(dS.contentIDList) == 0 && dS.backendErr == nil {
-		return nil, content.ErrContentNotFound
+}
+
+func TestContentByConceptHandler_Grouping(t *testing.T) {
+	log := logger.NewUPPLogger("test-service", "info")
+	contentURL := "/content?isAnnotatedBy=" + testConceptID
+
+	tests := []struct {
+		testName            string
+		url                 string
+		expectedStatusCode  int
+		expectedBody        string
+		expectedMaxPerGroup int
+		expectedGroupBy     content.GroupBy
+	}{
+		{
+			testName:           "Ungrouped by default",
+			url:                contentURL,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			testName:            "Capped per brand",
+			url:                 contentURL + "&maxPerGroup=2&groupBy=brand",
+			expectedStatusCode:  http.StatusOK,
+			expectedMaxPerGroup: 2,
+			expectedGroupBy:     content.GroupByBrand,
+		},
+		{
+			testName:           "Cap without groupBy",
+			url:                contentURL + "&maxPerGroup=2",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "groupBy", "Missing or empty query parameter groupBy, required with maxPerGroup. Expecting one of: brand, genre, type"),
+		},
+		{
+			testName:           "groupBy without cap",
+			url:                contentURL + "&groupBy=genre",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "maxPerGroup", "Missing or empty query parameter maxPerGroup, required with groupBy."),
+		},
+		{
+			testName:           "Unsupported groupBy",
+			url:                contentURL + "&maxPerGroup=2&groupBy=author",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "groupBy", "author is not a supported groupBy. Expecting one of: brand, genre, type"),
+		},
+		{
+			testName:           "Cap of zero",
+			url:                contentURL + "&maxPerGroup=0&groupBy=type",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "maxPerGroup", "provided value for maxPerGroup should not be less than: 1"),
+		},
+		{
+			testName:            "Last page within the depth cap",
+			url:                 contentURL + "&maxPerGroup=1&groupBy=type&page=20&limit=50",
+			expectedStatusCode:  http.StatusOK,
+			expectedMaxPerGroup: 1,
+			expectedGroupBy:     content.GroupByType,
+		},
+		{
+			testName:           "Page past the depth cap",
+			url:                contentURL + "&maxPerGroup=1&groupBy=type&page=21&limit=50",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "page", "page and limit should not go past the first 1000 pieces of content with maxPerGroup"),
+		},
+		{
+			testName:           "Deep page past the depth cap",
+			url:                contentURL + "&maxPerGroup=1&groupBy=type&page=9223372036854775807",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "page", "page and limit should not go past the first 1000 pieces of content with maxPerGroup"),
+		},
+		{
+			testName:           "Limit past the depth cap",
+			url:                contentURL + "&maxPerGroup=1&groupBy=type&limit=1001",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "page", "page and limit should not go past the first 1000 pieces of content with maxPerGroup"),
+		},
 	}
 
-	cntList := make([]content.Content, 0)
-	for _, contentID := range dS.contentIDList {
-		var con = content.Content{}
-		con.APIURL = apiURL(contentID)
-		con.ID = idURL(contentID)
-		cntList = append(cntList, con)
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			var params content.RequestParams
+			ds := recordingService{dummyService: dummyService{[]string{testContentUUID}, nil}, requestParams: &params}
+			handler := Handler{ContentService: &ds, CacheControlHeader: "10", Log: log}
+
+			rec := httptest.NewRecorder()
+			r := mux.NewRouter()
+			r.HandleFunc("/content", handler.GetContentByConcept).Methods("GET")
+			r.ServeHTTP(rec, newRequest("GET", test.url))
+
+			assert.Equal(t, test.expectedStatusCode, rec.Code)
+			if test.expectedBody != "" {
+				assert.JSONEq(t, test.expectedBody, rec.Body.String())
+			}
+			assert.Equal(t, test.expectedMaxPerGroup, params.MaxPerGroup)
+			assert.Equal(t, test.expectedGroupBy, params.GroupBy)
+		})
 	}
+}
+
+func TestContentByConceptHandler_NotFound(t *testing.T) {
+	log := logger.NewUPPLogger("test-service", "info")
+
+	tests := []struct {
+		testName           string
+		method             string
+		url                string
+		backendError       error
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			testName:           "Unknown concept",
+			url:                "/content?isAnnotatedBy=" + testConceptID + "&allowEmpty=true",
+			backendError:       content.ErrConceptNotFound,
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody:       notFoundBody(problem.TypeConceptNotFound, "No concept found with uuid "+testConceptID),
+		},
+		{
+			testName:           "Concept without content",
+			url:                "/content?isAnnotatedBy=" + testConceptID,
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody:       notFoundBody(problem.TypeContentNotFound, "No content found for concept with uuid "+testConceptID),
+		},
+		{
+			testName:           "Concept without content allowing empty results",
+			url:                "/content?isAnnotatedBy=" + testConceptID + "&allowEmpty=true",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "[]",
+		},
+		{
+			testName:           "Unknown concept for implicit content",
+			url:                "/content/" + testConceptID + "/implicitly",
+			backendError:       content.ErrConceptNotFound,
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody:       notFoundBody(problem.TypeConceptNotFound, "No concept found with uuid "+testConceptID),
+		},
+		{
+			testName:           "Concept without implicit content allowing empty results",
+			url:                "/content/" + testConceptID + "/implicitly?allowEmpty=true",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "[]",
+		},
+		{
+			testName:           "Invalid allowEmpty parameter",
+			url:                "/content?isAnnotatedBy=" + testConceptID + "&allowEmpty=maybe",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "allowEmpty", "provided value for allowEmpty, maybe, could not be parsed. Expecting true or false"),
+		},
+		{
+			testName:           "Invalid includeAnnotations parameter",
+			url:                "/content/" + testConceptID + "/implicitly?includeAnnotations=maybe",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "includeAnnotations", "provided value for includeAnnotations, maybe, could not be parsed. Expecting true or false"),
+		},
+		{
+			testName:           "Unknown endpoint",
+			url:                "/contents?isAnnotatedBy=" + testConceptID,
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody:       problemBody(http.StatusNotFound, "", "No endpoint found at /contents"),
+		},
+		{
+			testName:           "Method not allowed",
+			method:             http.MethodDelete,
+			url:                "/content?isAnnotatedBy=" + testConceptID,
+			expectedStatusCode: http.StatusMethodNotAllowed,
+			expectedBody:       problemBody(http.StatusMethodNotAllowed, "", "Method DELETE is not allowed at /content"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			ds := dummyService{nil, test.backendError}
+			handler := Handler{ContentService: &ds, CacheControlHeader: "10", Log: log}
+			method := http.MethodGet
+			if test.method != "" {
+				method = test.method
+			}
+
+			rec := httptest.NewRecorder()
+			r := mux.NewRouter()
+			r.NotFoundHandler = http.HandlerFunc(routeNotFound)
+			r.MethodNotAllowedHandler = http.HandlerFunc(methodNotAllowed)
+			r.HandleFunc("/content", handler.GetContentByConcept).Methods("GET")
+			r.HandleFunc("/content/{conceptUUID}/implicitly", handler.GetContentByConceptImplicitly).Methods("GET")
+			r.ServeHTTP(rec, newRequest(method, test.url))
+
+			assert.Equal(t, test.expectedStatusCode, rec.Code)
+			assert.Equal(t, test.expectedBody, strings.TrimSpace(rec.Body.String()))
+			if test.expectedStatusCode != http.StatusOK {
+				assert.Equal(t, problem.ContentType, rec.Header().Get("Content-Type"))
+			}
+			if test.expectedStatusCode == http.StatusOK {
+				assert.Equal(t, "0", rec.Header().Get("X-Result-Count"))
+				assert.Equal(t, testConceptID, rec.Header().Get("X-Canonical-Concept-UUID"))
+			}
+		})
+	}
+}
+
+func TestContentByConceptHandler_MergedConcept(t *testing.T) {
+	log := logger.NewUPPLogger("test-service", "info")
+
+	tests := []struct {
+		testName               string
+		url                    string
+		redirect               bool
+		withPolicy             bool
+		expectedStatusCode     int
+		expectedLocation       string
+		expectedContentSize    int
+		expectedContentQueries int
+	}{
+		{
+			testName:               "Content of the replacement",
+			url:                    "/content?isAnnotatedBy=http://api.ft.com/things/" + testConceptID + "&limit=10",
+			expectedStatusCode:     http.StatusOK,
+			expectedContentSize:    1,
+			expectedContentQueries: 1,
+		},
+		{
+			testName:           "Redirect to the replacement",
+			url:                "/content?isAnnotatedBy=http://api.ft.com/things/" + testConceptID + "&limit=10",
+			redirect:           true,
+			expectedStatusCode: http.StatusMovedPermanently,
+			expectedLocation:   "/content?isAnnotatedBy=http%3A%2F%2Fapi.ft.com%2Fthings%2F" + anotherConceptID + "&limit=10",
+		},
+		{
+			testName:           "Redirect without the publications added by the policy",
+			url:                "/content?isAnnotatedBy=http://api.ft.com/things/" + testConceptID + "&limit=10",
+			redirect:           true,
+			withPolicy:         true,
+			expectedStatusCode: http.StatusMovedPermanently,
+			expectedLocation:   "/content?isAnnotatedBy=http%3A%2F%2Fapi.ft.com%2Fthings%2F" + anotherConceptID + "&limit=10",
+		},
+		{
+			testName:               "Implicit content of the replacement",
+			url:                    "/content/" + testConceptID + "/implicitly",
+			expectedStatusCode:     http.StatusOK,
+			expectedContentSize:    1,
+			expectedContentQueries: 1,
+		},
+		{
+			testName:           "Redirect to the replacement for implicit content",
+			url:                "/content/" + testConceptID + "/implicitly?allowEmpty=true",
+			redirect:           true,
+			expectedStatusCode: http.StatusMovedPermanently,
+			expectedLocation:   "/content/" + anotherConceptID + "/implicitly?allowEmpty=true",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			var contentQueries int
+			ds := mergedConceptService{dummyService{[]string{testContentUUID}, nil}, anotherConceptID, &contentQueries}
+			handler := Handler{ContentService: &ds, CacheControlHeader: "10", Log: log, RedirectToCanonical: test.redirect}
+
+			rec := httptest.NewRecorder()
+			r := mux.NewRouter()
+			r.HandleFunc("/content", handler.GetContentByConcept).Methods("GET")
+			r.HandleFunc("/content/{conceptUUID}/implicitly", handler.GetContentByConceptImplicitly).Methods("GET")
+			var h http.Handler = r
+			if test.withPolicy {
+				h = keepClientQuery(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					policy.IsAuthorizedPublication(r, w, req, log, addFilterByPublication)
+				}))
+			}
+			h.ServeHTTP(rec, newRequest("GET", test.url))
+
+			assert.Equal(t, test.expectedStatusCode, rec.Code)
+			assert.Equal(t, test.expectedLocation, rec.Header().Get("Location"))
+			assert.Equal(t, anotherConceptID, rec.Header().Get("X-Canonical-Concept-UUID"))
+			assert.Equal(t, testConceptID, rec.Header().Get("X-Concept-Resolved-From"))
+			assert.Equal(t, test.expectedContentQueries, contentQueries)
+			if test.expectedStatusCode == http.StatusOK {
+				var body []content.Content
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+				assert.Len(t, body, test.expectedContentSize)
+			}
+		})
+	}
+}
+
+func TestContentByConceptHandler_ConceptIdentifiers(t *testing.T) {
+	log := logger.NewUPPLogger("test-service", "info")
+
+	tests := []struct {
+		testName           string
+		isAnnotatedBy      string
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			testName:           "Bare UUID",
+			isAnnotatedBy:      testConceptID,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			testName:           "www.ft.com things URI",
+			isAnnotatedBy:      "http://www.ft.com/things/" + testConceptID,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			testName:           "api.ft.com organisations URI",
+			isAnnotatedBy:      "https://api.ft.com/organisations/" + testConceptID,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			testName:           "api.ft.com people URI",
+			isAnnotatedBy:      "http://api.ft.com/people/" + testConceptID,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			testName:           "Authority identifier",
+			isAnnotatedBy:      "tme:" + testTMEIdentifier,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			testName:           "Unknown authority identifier",
+			isAnnotatedBy:      "TME:unknown",
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody:       notFoundBody(problem.TypeConceptNotFound, "No concept found with identifier TME:unknown"),
+		},
+		{
+			testName:           "Ambiguous authority identifier",
+			isAnnotatedBy:      "TME:" + testAmbiguousTMEIdentifier,
+			expectedStatusCode: http.StatusConflict,
+			expectedBody:       problemBody(http.StatusConflict, "isAnnotatedBy", "More than one concept found with identifier TME:"+testAmbiguousTMEIdentifier),
+		},
+		{
+			testName:           "URI of something other than a concept",
+			isAnnotatedBy:      "http://www.ft.com/content/" + testConceptID,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "isAnnotatedBy", "http://www.ft.com/content/"+testConceptID+" extracted from request URL was not valid uuid"),
+		},
+		{
+			testName:           "Unsupported authority",
+			isAnnotatedBy:      "DBPedia:John_Smith",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "isAnnotatedBy", "DBPedia is not a supported authority. Expecting one of: FACTSET, Smartlogic, TME, Wikidata"),
+		},
+		{
+			testName:           "Missing identifier",
+			isAnnotatedBy:      "Smartlogic:",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "isAnnotatedBy", "Missing identifier for authority Smartlogic"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			ds := dummyService{[]string{testContentUUID}, nil}
+			handler := Handler{ContentService: &ds, CacheControlHeader: "10", Log: log}
+
+			rec := httptest.NewRecorder()
+			r := mux.NewRouter()
+			r.HandleFunc("/content", handler.GetContentByConcept).Methods("GET")
+			r.ServeHTTP(rec, newRequest("GET", "/content?isAnnotatedBy="+url.QueryEscape(test.isAnnotatedBy)))
+
+			assert.Equal(t, test.expectedStatusCode, rec.Code)
+			if test.expectedBody != "" {
+				assert.Equal(t, test.expectedBody, rec.Body.String())
+			}
+			if test.expectedStatusCode == http.StatusOK {
+				assert.Equal(t, testConceptID, rec.Header().Get("X-Canonical-Concept-UUID"))
+			}
+		})
+	}
+}
+
+func TestContentByConceptHandler_IncludeConcept(t *testing.T) {
+	log := logger.NewUPPLogger("test-service", "info")
+	concept := `{"id":"http://www.ft.com/things/` + testConceptID + `","prefLabel":"Test Concept","type":"Person","concordedIds":["http://www.ft.com/things/` + testConceptID + `"]}`
+	contentItem := `{"id":"` + idURL(testContentUUID) + `","apiUrl":"` + apiURL(testContentUUID) + `"}`
+
+	tests := []struct {
+		testName     string
+		url          string
+		contentList  []string
+		expectedBody string
+	}{
+		{
+			testName:     "Content without the concept",
+			url:          "/content?isAnnotatedBy=" + testConceptID + "&includeConcept=false",
+			contentList:  []string{testContentUUID},
+			expectedBody: `[` + contentItem + `]`,
+		},
+		{
+			testName:     "Content with the concept",
+			url:          "/content?isAnnotatedBy=" + testConceptID + "&includeConcept=true",
+			contentList:  []string{testContentUUID},
+			expectedBody: `{"concept":` + concept + `,"content":[` + contentItem + `]}`,
+		},
+		{
+			testName:     "Implicit content with the concept",
+			url:          "/content/" + testConceptID + "/implicitly?includeConcept=true",
+			contentList:  []string{testContentUUID},
+			expectedBody: `{"concept":` + concept + `,"content":[` + contentItem + `]}`,
+		},
+		{
+			testName:     "Content with annotations",
+			url:          "/content?isAnnotatedBy=" + testConceptID + "&includeAnnotations=true",
+			contentList:  []string{testContentUUID},
+			expectedBody: `[{"id":"` + idURL(testContentUUID) + `","apiUrl":"` + apiURL(testContentUUID) + `","annotations":["about"]}]`,
+		},
+		{
+			testName:     "Content without any annotations",
+			url:          "/content/" + testConceptID + "/implicitly?includeAnnotations=true",
+			contentList:  []string{testContentUUID},
+			expectedBody: `[{"id":"` + idURL(testContentUUID) + `","apiUrl":"` + apiURL(testContentUUID) + `","annotations":[]}]`,
+		},
+		{
+			testName:     "Concept without content",
+			url:          "/content?isAnnotatedBy=" + testConceptID + "&includeConcept=true&allowEmpty=true",
+			expectedBody: `{"concept":` + concept + `,"content":[]}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			ds := dummyService{test.contentList, nil}
+			handler := Handler{ContentService: &ds, CacheControlHeader: "10", Log: log}
+
+			rec := httptest.NewRecorder()
+			r := mux.NewRouter()
+			r.HandleFunc("/content", handler.GetContentByConcept).Methods("GET")
+			r.HandleFunc("/content/{conceptUUID}/implicitly", handler.GetContentByConceptImplicitly).Methods("GET")
+			r.ServeHTTP(rec, newRequest("GET", test.url))
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.JSONEq(t, test.expectedBody, rec.Body.String())
+		})
+	}
+}
+
+func TestForwardedAPIURL(t *testing.T) {
+	tests := []struct {
+		testName       string
+		forwardedHost  string
+		forwardedProto string
+		expectedAPIURL string
+	}{
+		{
+			testName: "Request without forwarded headers",
+		},
+		{
+			testName:       "Request forwarded by a trusted host",
+			forwardedHost:  "api-t.ft.com",
+			expectedAPIURL: "https://api-t.ft.com",
+		},
+		{
+			testName:       "Request forwarded by a trusted host over http",
+			forwardedHost:  "API-T.ft.com, proxy.internal",
+			forwardedProto: "http, https",
+			expectedAPIURL: "http://API-T.ft.com",
+		},
+		{
+			testName:       "Request forwarded by an untrusted host",
+			forwardedHost:  "evil.example.com",
+			forwardedProto: "https",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			var apiURL string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				apiURL, _ = content.APIURLFromContext(r.Context())
+				w.Header().Set("Cache-Control", "max-age=10")
+			})
+
+			req := newRequest("GET", "/content")
+			if test.forwardedHost != "" {
+				req.Header.Set("X-Forwarded-Host", test.forwardedHost)
+			}
+			if test.forwardedProto != "" {
+				req.Header.Set("X-Forwarded-Proto", test.forwardedProto)
+			}
+			rec := httptest.NewRecorder()
+			forwardedAPIURL([]string{"api-t.ft.com", "api.ft.com"})(next).ServeHTTP(rec, req)
+
+			assert.Equal(t, test.expectedAPIURL, apiURL)
+			assert.Equal(t, "X-Forwarded-Host, X-Forwarded-Proto", rec.Header().Get("Vary"))
+		})
+	}
+}
+
+func TestContentByConceptHandler_GetContentByConcepts(t *testing.T) {
+	log := logger.NewUPPLogger("test-service", "info")
+	contentList := `[{"id":"` + idURL(testContentUUID) + `","apiUrl":"` + apiURL(testContentUUID) + `"}]`
+
+	tests := []struct {
+		testName           string
+		url                string
+		body               string
+		backendError       error
+		maxBatchSize       int
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			testName:           "Success for concepts given by UUID and URI",
+			url:                "/content/batch",
+			body:               `{"concepts":[{"isAnnotatedBy":"` + testConceptID + `","limit":10},{"isAnnotatedBy":"http://api.ft.com/things/` + anotherConceptID + `","page":2}]}`,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"results":{"` + testConceptID + `":{"content":` + contentList + `},"http://api.ft.com/things/` + anotherConceptID + `":{"content":` + contentList + `}}}`,
+		},
+		{
+			testName:           "Success with the concepts",
+			url:                "/content/batch?includeConcept=true",
+			body:               `{"concepts":[{"isAnnotatedBy":"` + testConceptID + `"}]}`,
+			expectedStatusCode: http.StatusOK,
+			expectedBody: `{"results":{"` + testConceptID + `":{"concept":{"id":"http://www.ft.com/things/` + testConceptID +
+				`","prefLabel":"Test Concept","type":"Person","concordedIds":["http://www.ft.com/things/` + testConceptID + `"]},"content":` + contentList + `}}}`,
+		},
+		{
+			testName:           "Errors for some of the concepts",
+			url:                "/content/batch",
+			body:               `{"concepts":[{"isAnnotatedBy":"` + testConceptID + `"},{"isAnnotatedBy":"123456"},{"isAnnotatedBy":"TME:abc"},{"isAnnotatedBy":"` + anotherConceptID + `","page":0,"fromDate":"2018-13-01"}]}`,
+			expectedStatusCode: http.StatusOK,
+			expectedBody: `{"results":{"` + testConceptID + `":{"content":` + contentList + `},` +
+				`"123456":{"error":` + batchProblem(http.StatusBadRequest, "isAnnotatedBy", "123456 extracted from request URL was not valid uuid") + `},` +
+				`"TME:abc":{"error":` + batchProblem(http.StatusBadRequest, "isAnnotatedBy", "Authority identifiers are not supported in batches. Expecting a concept UUID or URI.") + `},` +
+				`"` + anotherConceptID + `":{"error":` + batchProblem(http.StatusBadRequest, "fromDate", "From date value 2018-13-01 could not be parsed") + `}}}`,
+		},
+		{
+			testName:           "Backend errors for the concepts",
+			url:                "/content/batch",
+			body:               `{"concepts":[{"isAnnotatedBy":"` + testConceptID + `"}]}`,
+			backendError:       errors.New("db unavailable"),
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"results":{"` + testConceptID + `":{"error":` + batchProblem(http.StatusServiceUnavailable, "", "Backend error returning content for concept with uuid "+testConceptID) + `}}}`,
+		},
+		{
+			testName:           "Unknown concepts",
+			url:                "/content/batch",
+			body:               `{"concepts":[{"isAnnotatedBy":"` + testConceptID + `"}]}`,
+			backendError:       content.ErrConceptNotFound,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"results":{"` + testConceptID + `":{"error":` + batchNotFound(problem.TypeConceptNotFound, "No concept found with uuid "+testConceptID) + `}}}`,
+		},
+		{
+			testName:           "Invalid body",
+			url:                "/content/batch",
+			body:               `{"concepts":`,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "", "Could not parse the request body: unexpected EOF"),
+		},
+		{
+			testName:           "Body over the cap",
+			url:                "/content/batch",
+			body:               `{"concepts":[{"isAnnotatedBy":"` + strings.Repeat("a", maxBatchBodySize) + `"}]}`,
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+			expectedBody:       problemBody(http.StatusRequestEntityTooLarge, "", "Request body exceeds the maximum of 1048576 bytes"),
+		},
+		{
+			testName:           "Empty batch",
+			url:                "/content/batch",
+			body:               `{"concepts":[]}`,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "concepts", "Missing or empty concepts. Expecting at least one concept."),
+		},
+		{
+			testName:           "Batch over the cap",
+			url:                "/content/batch",
+			body:               `{"concepts":[{"isAnnotatedBy":"` + testConceptID + `"},{"isAnnotatedBy":"` + anotherConceptID + `"}]}`,
+			maxBatchSize:       1,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "concepts", "Batch of 2 concepts exceeds the maximum of 1"),
+		},
+		{
+			testName:           "Concept asked for twice",
+			url:                "/content/batch",
+			body:               `{"concepts":[{"isAnnotatedBy":"` + testConceptID + `"},{"isAnnotatedBy":"` + testConceptID + `","limit":5}]}`,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "concepts", "Concept "+testConceptID+" is asked for more than once"),
+		},
+		{
+			testName:           "Unknown fields in strict batch",
+			url:                "/content/batch?strict=true",
+			body:               `{"concepts":[{"isAnnotatedBy":"` + testConceptID + `","order":"asc"}]}`,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "", `Could not parse the request body: json: unknown field "order"`),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			ds := dummyService{[]string{testContentUUID}, test.backendError}
+			handler := Handler{ContentService: &ds, CacheControlHeader: "10", Log: log, MaxBatchSize: test.maxBatchSize}
+
+			rec := httptest.NewRecorder()
+			r := mux.NewRouter()
+			r.HandleFunc("/content/batch", handler.GetContentByConcepts).Methods("POST")
+
+			req := newRequest("POST", test.url)
+			req.Body = io.NopCloser(strings.NewReader(test.body))
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, test.expectedStatusCode, rec.Code)
+			assert.JSONEq(t, test.expectedBody, rec.Body.String())
+		})
+	}
+}
+
+func TestContentByConceptHandler_GetContentByConceptsWithPolicy(t *testing.T) {
+	log := logger.NewUPPLogger("test-service", "info")
+	const otherPublication = "19d50190-8656-4e91-8d34-82e646ada9c9"
+
+	tests := []struct {
+		testName            string
+		body                string
+		expectedPublication [][]string
+		expectedBody        string
+	}{
+		{
+			testName:            "Publications of the policy",
+			body:                `{"concepts":[{"isAnnotatedBy":"` + testConceptID + `"}]}`,
+			expectedPublication: [][]string{{"88fdde6c-2aa4-4f78-af02-9f680097cfd6", "8e6c705e-1132-42a2-8db0-c295e29e8658"}},
+		},
+		{
+			testName:            "Publications narrowed down within the policy",
+			body:                `{"concepts":[{"isAnnotatedBy":"` + testConceptID + `","publication":["8e6c705e-1132-42a2-8db0-c295e29e8658"]}]}`,
+			expectedPublication: [][]string{{"8e6c705e-1132-42a2-8db0-c295e29e8658"}},
+		},
+		{
+			testName: "Publications outside the policy",
+			body: `{"concepts":[{"isAnnotatedBy":"` + testConceptID + `","publication":["8e6c705e-1132-42a2-8db0-c295e29e8658","` + otherPublication + `"]},` +
+				`{"isAnnotatedBy":"` + anotherConceptID + `","publication":["8e6c705e-1132-42a2-8db0-c295e29e8658,` + otherPublication + `"]}]}`,
+			expectedBody: `{"results":{"` + testConceptID + `":{"error":` + batchProblem(http.StatusForbidden, "publication", "publication is not allowed by the access policy: "+otherPublication) + `},` +
+				`"` + anotherConceptID + `":{"error":` + batchProblem(http.StatusForbidden, "publication", "publication is not allowed by the access policy: "+otherPublication) + `}}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			var queries []content.ConceptQuery
+			ds := recordingService{dummyService: dummyService{[]string{testContentUUID}, nil}, queries: &queries}
+			handler := Handler{ContentService: &ds, CacheControlHeader: "10", Log: log}
+
+			rec := httptest.NewRecorder()
+			r := mux.NewRouter()
+			r.HandleFunc("/content/batch", handler.GetContentByConcepts).Methods("POST")
+
+			req := newRequest("POST", "/content/batch")
+			req.Body = io.NopCloser(strings.NewReader(test.body))
+			policy.IsAuthorizedPublication(r, rec, req, log, addFilterByPublication)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			var publication [][]string
+			for _, query := range queries {
+				publication = append(publication, query.Params.Publication)
+			}
+			assert.Equal(t, test.expectedPublication, publication)
+			if test.expectedBody != "" {
+				assert.JSONEq(t, test.expectedBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestContentByConceptHandler_GetContentCount(t *testing.T) {
+	log := logger.NewUPPLogger("test-service", "info")
+
+	tests := []struct {
+		testName           string
+		url                string
+		backendError       error
+		maxBatchSize       int
+		expectedStatusCode int
+		expectedBody       string
+		expectedParams     content.CountParams
+	}{
+		{
+			testName:           "Counts keyed by isAnnotatedBy",
+			url:                "/content/count?isAnnotatedBy=" + testConceptID + ",http://api.ft.com/things/" + anotherConceptID,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"counts":{"` + testConceptID + `":1,"http://api.ft.com/things/` + anotherConceptID + `":1}}`,
+		},
+		{
+			testName:           "Repeated isAnnotatedBy with filters",
+			url:                "/content/count?isAnnotatedBy=" + testConceptID + "&isAnnotatedBy=" + anotherConceptID + "&isAnnotatedBy=" + testConceptID + "&fromDate=2018-01-01&toDate=2018-02-01&type=Article,http://www.ft.com/ontology/content/Video&predicate=http://www.ft.com/ontology/annotation/about&predicate=mentions&implicit=true",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"counts":{"` + testConceptID + `":1,"` + anotherConceptID + `":1}}`,
+			expectedParams: content.CountParams{
+				FromDateEpoch: 1514764800,
+				ToDateEpoch:   1517443200,
+				Dated:         true,
+				ContentTypes:  []string{"Article", "Video"},
+				Predicates:    []string{"about", "mentions"},
+				Implicit:      true,
+			},
+		},
+		{
+			testName:           "Missing isAnnotatedBy",
+			url:                "/content/count?type=Article",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "isAnnotatedBy", "Missing or empty query parameter isAnnotatedBy. Expecting valid absolute concept URI."),
+		},
+		{
+			testName:           "Dates from the epoch",
+			url:                "/content/count?isAnnotatedBy=" + testConceptID + "&fromDate=1970-01-01&toDate=2018-01-01",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"counts":{"` + testConceptID + `":1}}`,
+			expectedParams:     content.CountParams{FromDateEpoch: 0, ToDateEpoch: 1514764800, Dated: true},
+		},
+		{
+			testName:           "Parameters of other endpoints are ignored",
+			url:                "/content/count?isAnnotatedBy=" + testConceptID + "&page=0&limit=-1&sort=oldest-first&maxPerGroup=none",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"counts":{"` + testConceptID + `":1}}`,
+		},
+		{
+			testName:           "Dates out of order in strict mode",
+			url:                "/content/count?isAnnotatedBy=" + testConceptID + "&fromDate=2018-02-01&toDate=2018-01-01&strict=true",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "fromDate", "From date 2018-02-01 should be before to date 2018-01-01"),
+		},
+		{
+			testName:           "Too many concepts",
+			url:                "/content/count?isAnnotatedBy=" + testConceptID + "," + anotherConceptID,
+			maxBatchSize:       1,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "isAnnotatedBy", "2 concepts exceed the maximum of 1"),
+		},
+		{
+			testName:           "Authority identifier",
+			url:                "/content/count?isAnnotatedBy=TME:" + testTMEIdentifier,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "isAnnotatedBy", "Authority identifiers are not supported when counting. Expecting a concept UUID or URI."),
+		},
+		{
+			testName:           "Invalid type",
+			url:                "/content/count?isAnnotatedBy=" + testConceptID + "&type=Article-Video",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "type", "Article-Video is not a valid content type"),
+		},
+		{
+			testName:           "Unknown predicate",
+			url:                "/content/count?isAnnotatedBy=" + testConceptID + "&predicate=likes",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody: problemBody(http.StatusBadRequest, "predicate", "likes is not a supported predicate. Expecting one of: "+
+				"about, hasAuthor, hasContributor, hasDisplayTag, implicitlyAbout, implicitlyClassifiedBy, isClassifiedBy, isPrimarilyClassifiedBy, majorMentions, mentions"),
+		},
+		{
+			testName:           "Invalid implicit",
+			url:                "/content/count?isAnnotatedBy=" + testConceptID + "&implicit=yes",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "implicit", "provided value for implicit, yes, could not be parsed. Expecting true or false"),
+		},
+		{
+			testName:           "Unknown parameter in strict mode",
+			url:                "/content/count?isAnnotatedBy=" + testConceptID + "&limit=10&strict=true",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody: problemBody(http.StatusBadRequest, "limit", "unknown query parameters: limit. Valid parameters are: "+
+				"isAnnotatedBy, fromDate, toDate, publication, type, predicate, implicit, strict"),
+		},
+		{
+			testName:           "Backend error",
+			url:                "/content/count?isAnnotatedBy=" + testConceptID,
+			backendError:       errors.New("db unavailable"),
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedBody:       problemBody(http.StatusServiceUnavailable, "", "Backend error counting content for concepts"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			var params content.CountParams
+			ds := recordingService{dummyService: dummyService{[]string{testContentUUID}, test.backendError}, countParams: &params}
+			handler := Handler{ContentService: &ds, CacheControlHeader: "10", Log: log, MaxBatchSize: test.maxBatchSize}
+
+			rec := httptest.NewRecorder()
+			r := mux.NewRouter()
+			r.HandleFunc("/content/count", handler.GetContentCount).Methods("GET")
+			r.ServeHTTP(rec, newRequest("GET", test.url))
+
+			assert.Equal(t, test.expectedStatusCode, rec.Code)
+			assert.JSONEq(t, test.expectedBody, rec.Body.String())
+			if test.expectedStatusCode == http.StatusOK {
+				assert.Equal(t, "10", rec.Header().Get("Cache-Control"))
+				assert.Equal(t, test.expectedParams, params)
+			}
+		})
+	}
+}
+
+func TestContentByConceptHandler_GetContentHistogram(t *testing.T) {
+	log := logger.NewUPPLogger("test-service", "info")
+
+	tests := []struct {
+		testName           string
+		url                string
+		backendError       error
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			testName:           "Weekly histogram",
+			url:                "/content/histogram?isAnnotatedBy=" + testConceptID + "&interval=week&fromDate=2018-01-01&toDate=2018-02-01",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"interval":"week","buckets":[{"date":"2018-01-01","count":1}]}`,
+		},
+		{
+			testName:           "Daily histogram by default for an authority identifier",
+			url:                "/content/histogram?isAnnotatedBy=TME:" + testTMEIdentifier + "&fromDate=2018-01-01&toDate=2018-01-02",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"interval":"day","buckets":[{"date":"2018-01-01","count":1}]}`,
+		},
+		{
+			testName:           "Ambiguous authority identifier",
+			url:                "/content/histogram?isAnnotatedBy=TME:" + testAmbiguousTMEIdentifier + "&fromDate=2018-01-01&toDate=2018-01-02",
+			expectedStatusCode: http.StatusConflict,
+			expectedBody:       problemBody(http.StatusConflict, "isAnnotatedBy", "More than one concept found with identifier TME:"+testAmbiguousTMEIdentifier),
+		},
+		{
+			testName:           "Unknown interval",
+			url:                "/content/histogram?isAnnotatedBy=" + testConceptID + "&interval=hour&fromDate=2018-01-01&toDate=2018-02-01",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "interval", "hour is not a supported interval. Expecting one of: day, week, month"),
+		},
+		{
+			testName:           "Missing toDate",
+			url:                "/content/histogram?isAnnotatedBy=" + testConceptID + "&fromDate=2018-01-01",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "toDate", "Missing or empty query parameter toDate. Expecting a date in YYYY-MM-DD format."),
+		},
+		{
+			testName:           "Dates out of order",
+			url:                "/content/histogram?isAnnotatedBy=" + testConceptID + "&fromDate=2018-02-01&toDate=2018-01-01",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "fromDate", "From date 2018-02-01 should be before to date 2018-01-01"),
+		},
+		{
+			testName:           "Too many buckets",
+			url:                "/content/histogram?isAnnotatedBy=" + testConceptID + "&fromDate=2000-01-01&toDate=2018-01-01",
+			backendError:       content.ErrTooManyBuckets,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "interval", "histograms cannot have more than 1000 buckets between 2000-01-01 and 2018-01-01"),
+		},
+		{
+			testName:           "Concept not found",
+			url:                "/content/histogram?isAnnotatedBy=" + testConceptID + "&fromDate=2018-01-01&toDate=2018-02-01",
+			backendError:       content.ErrConceptNotFound,
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody:       notFoundBody(problem.TypeConceptNotFound, "No concept found with uuid "+testConceptID),
+		},
+		{
+			testName:           "Backend error",
+			url:                "/content/histogram?isAnnotatedBy=" + testConceptID + "&fromDate=2018-01-01&toDate=2018-02-01",
+			backendError:       errors.New("db unavailable"),
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedBody:       problemBody(http.StatusServiceUnavailable, "", "Backend error returning content histogram for concept with uuid "+testConceptID),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			ds := dummyService{[]string{testContentUUID}, test.backendError}
+			handler := Handler{ContentService: &ds, CacheControlHeader: "10", Log: log}
+
+			rec := httptest.NewRecorder()
+			r := mux.NewRouter()
+			r.HandleFunc("/content/histogram", handler.GetContentHistogram).Methods("GET")
+			r.ServeHTTP(rec, newRequest("GET", test.url))
+
+			assert.Equal(t, test.expectedStatusCode, rec.Code)
+			assert.JSONEq(t, test.expectedBody, rec.Body.String())
+		})
+	}
+}
+
+func TestContentByConceptHandler_GetTrendingConcepts(t *testing.T) {
+	log := logger.NewUPPLogger("test-service", "info")
+
+	tests := []struct {
+		testName           string
+		url                string
+		backendError       error
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			testName:           "Default windows",
+			url:                "/concepts/trending?type=Organisation",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"concepts":[{"id":"http://www.ft.com/things/` + testConceptID + `","prefLabel":"Test Concept","type":"Organisation","recentCount":1,"baselineCount":0,"growth":7}]}`,
+		},
+		{
+			testName:           "Windows and types by URI",
+			url:                "/concepts/trending?window=12h&baseline=48h&type=http://www.ft.com/ontology/person/Person&limit=5&minCount=1",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"concepts":[{"id":"http://www.ft.com/things/` + testConceptID + `","prefLabel":"Test Concept","type":"Person","recentCount":1,"baselineCount":0,"growth":4}]}`,
+		},
+		{
+			testName:           "No concepts",
+			url:                "/concepts/trending",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"concepts":[]}`,
+		},
+		{
+			testName:           "Invalid window",
+			url:                "/concepts/trending?window=1d",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "window", "provided value for window, 1d, could not be parsed. Expecting a positive duration, e.g. 24h"),
+		},
+		{
+			testName:           "Windows too long",
+			url:                "/concepts/trending?window=720h&baseline=1500h",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "baseline", "window and baseline together should not be longer than 2160h0m0s"),
+		},
+		{
+			testName:           "Window too long",
+			url:                "/concepts/trending?window=2200h&baseline=1h",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "window", "window should not be longer than 2160h0m0s"),
+		},
+		{
+			testName:           "Windows overflowing when added",
+			url:                "/concepts/trending?window=24h&baseline=2562047h",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "baseline", "window and baseline together should not be longer than 2160h0m0s"),
+		},
+		{
+			testName:           "Window overflowing when added",
+			url:                "/concepts/trending?window=2562047h&baseline=24h",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "window", "window should not be longer than 2160h0m0s"),
+		},
+		{
+			testName:           "Limit too high",
+			url:                "/concepts/trending?limit=500",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "limit", "provided value for limit should not be greater than: 100"),
+		},
+		{
+			testName:           "Invalid minCount",
+			url:                "/concepts/trending?minCount=0",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "minCount", "provided value for minCount should not be less than: 1"),
+		},
+		{
+			testName:           "Invalid type",
+			url:                "/concepts/trending?type=Organi-sation",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "type", "Organi-sation is not a valid concept type"),
+		},
+		{
+			testName:           "Backend error",
+			url:                "/concepts/trending",
+			backendError:       errors.New("db unavailable"),
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedBody:       problemBody(http.StatusServiceUnavailable, "", "Backend error returning trending concepts"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			ds := dummyService{[]string{testContentUUID}, test.backendError}
+			handler := Handler{ContentService: &ds, CacheControlHeader: "10", Log: log}
+
+			rec := httptest.NewRecorder()
+			r := mux.NewRouter()
+			r.HandleFunc("/concepts/trending", handler.GetTrendingConcepts).Methods("GET")
+			r.ServeHTTP(rec, newRequest("GET", test.url))
+
+			assert.Equal(t, test.expectedStatusCode, rec.Code)
+			assert.JSONEq(t, test.expectedBody, rec.Body.String())
+		})
+	}
+}
+
+func TestContentByConceptHandler_GetCooccurringConcepts(t *testing.T) {
+	log := logger.NewUPPLogger("test-service", "info")
+	related := `{"concepts":[{"id":"http://www.ft.com/things/` + anotherConceptID + `","prefLabel":"Another Concept","type":"Organisation","count":1,"similarity":0.5}]}`
+
+	tests := []struct {
+		testName           string
+		url                string
+		contentList        []string
+		backendError       error
+		expectedStatusCode int
+		expectedBody       string
+		expectedParams     content.CooccurrenceParams
+	}{
+		{
+			testName:           "Co-occurring concepts",
+			url:                "/concepts/" + testConceptID + "/cooccurring",
+			contentList:        []string{testContentUUID},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       related,
+			expectedParams:     content.CooccurrenceParams{Limit: 20},
+		},
+		{
+			testName:           "Filters",
+			url:                "/concepts/" + testConceptID + "/cooccurring?fromDate=2018-01-01&toDate=2018-02-01&publication=8e6c705e-1132-42a2-8db0-c295e29e8658&type=Organisation&predicate=about&limit=5",
+			contentList:        []string{testContentUUID},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       related,
+			expectedParams: content.CooccurrenceParams{
+				FromDateEpoch: 1514764800,
+				ToDateEpoch:   1517443200,
+				Dated:         true,
+				Publication:   []string{"8e6c705e-1132-42a2-8db0-c295e29e8658"},
+				ConceptTypes:  []string{"Organisation"},
+				Predicates:    []string{"about"},
+				Limit:         5,
+			},
+		},
+		{
+			testName:           "No co-occurring concepts",
+			url:                "/concepts/" + testConceptID + "/cooccurring",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"concepts":[]}`,
+			expectedParams:     content.CooccurrenceParams{Limit: 20},
+		},
+		{
+			testName:           "Invalid concept UUID",
+			url:                "/concepts/123456/cooccurring",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "conceptUUID", "123456 extracted from request URL was not valid uuid"),
+		},
+		{
+			testName:           "Limit too high",
+			url:                "/concepts/" + testConceptID + "/cooccurring?limit=101",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "limit", "provided value for limit should not be greater than: 100"),
+		},
+		{
+			testName:           "Unknown predicate",
+			url:                "/concepts/" + testConceptID + "/cooccurring?predicate=http://www.ft.com/ontology/annotation/likes",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody: problemBody(http.StatusBadRequest, "predicate", "http://www.ft.com/ontology/annotation/likes is not a supported predicate. Expecting one of: "+
+				"about, hasAuthor, hasContributor, hasDisplayTag, implicitlyAbout, implicitlyClassifiedBy, isClassifiedBy, isPrimarilyClassifiedBy, majorMentions, mentions"),
+		},
+		{
+			testName:           "Concept not found",
+			url:                "/concepts/" + testConceptID + "/cooccurring",
+			backendError:       content.ErrConceptNotFound,
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody:       notFoundBody(problem.TypeConceptNotFound, "No concept found with uuid "+testConceptID),
+		},
+		{
+			testName:           "Backend error",
+			url:                "/concepts/" + testConceptID + "/cooccurring",
+			backendError:       errors.New("db unavailable"),
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedBody:       problemBody(http.StatusServiceUnavailable, "", "Backend error returning co-occurring concepts for concept with uuid "+testConceptID),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			var params content.CooccurrenceParams
+			ds := recordingService{dummyService: dummyService{test.contentList, test.backendError}, cooccurrenceParams: &params}
+			handler := Handler{ContentService: &ds, CacheControlHeader: "10", Log: log}
+
+			rec := httptest.NewRecorder()
+			r := mux.NewRouter()
+			r.HandleFunc("/concepts/{conceptUUID}/cooccurring", handler.GetCooccurringConcepts).Methods("GET")
+			r.ServeHTTP(rec, newRequest("GET", test.url))
+
+			assert.Equal(t, test.expectedStatusCode, rec.Code)
+			assert.JSONEq(t, test.expectedBody, rec.Body.String())
+			if test.expectedStatusCode == http.StatusOK {
+				assert.Equal(t, test.expectedParams, params)
+			}
+		})
+	}
+}
+
+func TestContentByConceptHandler_GetConceptExpansion(t *testing.T) {
+	log := logger.NewUPPLogger("test-service", "info")
+
+	tests := []struct {
+		testName           string
+		url                string
+		backendError       error
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			testName:           "Expansion",
+			url:                "/concepts/" + testConceptID + "/expansion",
+			expectedStatusCode: http.StatusOK,
+			expectedBody: `{"concepts":[` +
+				`{"id":"http://www.ft.com/things/` + testConceptID + `","prefLabel":"Test Concept","type":"Location","relationships":[],"depth":0},` +
+				`{"id":"http://www.ft.com/things/` + anotherConceptID + `","prefLabel":"Another Concept","type":"Location","relationships":["IS_PART_OF"],"depth":1}]}`,
+		},
+		{
+			testName:           "Invalid concept UUID",
+			url:                "/concepts/123456/expansion",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "conceptUUID", "123456 extracted from request URL was not valid uuid"),
+		},
+		{
+			testName:           "Expansion with subsidiaries",
+			url:                "/concepts/" + testConceptID + "/expansion?subsidiaryDepth=2",
+			expectedStatusCode: http.StatusOK,
+			expectedBody: `{"concepts":[` +
+				`{"id":"http://www.ft.com/things/` + testConceptID + `","prefLabel":"Test Concept","type":"Location","relationships":[],"depth":0},` +
+				`{"id":"http://www.ft.com/things/` + anotherConceptID + `","prefLabel":"Another Concept","type":"Location","relationships":["IS_PART_OF"],"depth":1},` +
+				`{"id":"http://www.ft.com/things/` + subsidiaryConceptID + `","prefLabel":"Subsidiary","type":"Organisation","relationships":["SUB_ORGANISATION_OF"],"depth":1}]}`,
+		},
+		{
+			testName:           "Subsidiary depth over the maximum",
+			url:                "/concepts/" + testConceptID + "/expansion?subsidiaryDepth=4",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "subsidiaryDepth", "provided value for subsidiaryDepth should not be greater than: 3"),
+		},
+		{
+			testName:           "Unknown parameter in strict mode",
+			url:                "/concepts/" + testConceptID + "/expansion?depth=1&strict=true",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       problemBody(http.StatusBadRequest, "depth", "unknown query parameters: depth. Valid parameters are: subsidiaryDepth, strict"),
+		},
+		{
+			testName:           "Concept not found",
+			url:                "/concepts/" + testConceptID + "/expansion",
+			backendError:       content.ErrConceptNotFound,
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody:       notFoundBody(problem.TypeConceptNotFound, "No concept found with uuid "+testConceptID),
+		},
+		{
+			testName:           "Backend error",
+			url:                "/concepts/" + testConceptID + "/expansion",
+			backendError:       errors.New("db unavailable"),
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedBody:       problemBody(http.StatusServiceUnavailable, "", "Backend error returning expansion for concept with uuid "+testConceptID),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			ds := dummyService{[]string{testContentUUID}, test.backendError}
+			handler := Handler{ContentService: &ds, CacheControlHeader: "10", Log: log, MaxSubsidiaryDepth: 3}
+
+			rec := httptest.NewRecorder()
+			r := mux.NewRouter()
+			r.HandleFunc("/concepts/{conceptUUID}/expansion", handler.GetConceptExpansion).Methods("GET")
+			r.ServeHTTP(rec, newRequest("GET", test.url))
+
+			assert.Equal(t, test.expectedStatusCode, rec.Code)
+			assert.JSONEq(t, test.expectedBody, rec.Body.String())
+		})
+	}
+}
+
+func buildURL(conceptID, fromDate, toDate, page, contentLimit string, publication []string) string {
+	var URL = fmt.Sprintf("/content?isAnnotatedBy=http://api.ft.com/things/%s", conceptID)
+	if fromDate != "" {
+		URL = URL + fmt.Sprintf("&fromDate=%s", fromDate)
+	}
+	if toDate != "" {
+		URL = URL + fmt.Sprintf("&toDate=%s", toDate)
+	}
+	if contentLimit != "" {
+		URL = URL + fmt.Sprintf("&limit=%s", contentLimit)
+	}
+	if page != "" {
+		URL = URL + fmt.Sprintf("&page=%s", page)
+	}
+	if len(publication) != 0 {
+		URL = URL + fmt.Sprintf("&publication=%s", strings.Join(publication, ","))
+	}
+	return URL
+}
+
+func newRequest(method, url string) *http.Request {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		panic(err)
+	}
+	req.Header.Set("X-Request-Id", testTransactionID)
+	return req
+}
+
+func problemBody(status int, param, detail string) string {
+	details := problem.New(status, detail).WithParam(param)
+	details.TransactionID = testTransactionID
+
+	body, err := json.Marshal(details)
+	if err != nil {
+		panic(err)
+	}
+	return string(body)
+}
+
+// batchNotFound is the JSON of the 404 problem details of the given type for one of the concepts of a batch.
+func batchNotFound(typ, detail string) string {
+	body, err := json.Marshal(problem.New(http.StatusNotFound, detail).WithType(typ))
+	if err != nil {
+		panic(err)
+	}
+	return string(body)
+}
+
+// notFoundBody is the JSON of the 404 problem details of the given type.
+func notFoundBody(typ, detail string) string {
+	details := problem.New(http.StatusNotFound, detail).WithType(typ)
+	details.TransactionID = testTransactionID
+
+	body, err := json.Marshal(details)
+	if err != nil {
+		panic(err)
+	}
+	return string(body)
+}
+
+// batchProblem is the JSON of the problem details for one of the concepts of a batch.
+func batchProblem(status int, param, detail string) string {
+	body, err := json.Marshal(problem.New(status, detail).WithParam(param))
+	if err != nil {
+		panic(err)
+	}
+	return string(body)
+}
+
+type dummyService struct {
+	contentIDList []string
+	backendErr    error
+}
+
+func (dS dummyService) GetContentForConcept(_ context.Context, conceptUUID string, params content.RequestParams) (content.ConceptContent, error) {
+	if dS.backendErr != nil {
+		return content.ConceptContent{}, dS.backendErr
+	}
+	if len(dS.contentIDList) == 0 && dS.backendErr == nil {
+		return content.ConceptContent{CanonicalUUID: conceptUUID, Concept: testConcept(conceptUUID)}, content.ErrContentNotFound
+	}
+
+	cntList := make([]content.Content, 0)
+	for _, contentID := range dS.contentIDList {
+		var con = content.Content{}
+		con.APIURL = apiURL(contentID)
+		con.ID = idURL(contentID)
+		if params.IncludeAnnotations {
+			con.Annotations = []string{"about"}
+		}
+		cntList = append(cntList, con)
+	}
+
+	return content.ConceptContent{CanonicalUUID: conceptUUID, Concept: testConcept(conceptUUID), Content: cntList}, nil
+}
+
+func (dS dummyService) GetContentForConceptImplicitly(_ context.Context, conceptUUID string, params content.ImplicitParams) (content.ConceptContent, error) {
+	if dS.backendErr != nil {
+		return content.ConceptContent{}, dS.backendErr
+	}
+	if len(dS.contentIDList) == 0 && dS.backendErr == nil {
+		return content.ConceptContent{CanonicalUUID: conceptUUID, Concept: testConcept(conceptUUID)}, content.ErrContentNotFound
+	}
+
+	cntList := make([]content.Content, 0)
+	for _, contentID := range dS.contentIDList {
+		var con = content.Content{}
+		con.APIURL = apiURL(contentID)
+		con.ID = idURL(contentID)
+		if params.IncludeAnnotations {
+			// found through relationships none of which are annotation predicates
+			con.Annotations = []string{}
+		}
+		cntList = append(cntList, con)
+	}
+
+	return content.ConceptContent{CanonicalUUID: conceptUUID, Concept: testConcept(conceptUUID), Content: cntList}, nil
+}
+
+func (dS dummyService) ProfileContentForConcept(ctx context.Context, conceptUUID string, params content.RequestParams) (content.ConceptContent, *content.QueryProfile, error) {
+	result, err := dS.GetContentForConcept(ctx, conceptUUID, params)
+	return result, testProfile(), err
+}
+
+func (dS dummyService) ProfileContentForConceptImplicitly(ctx context.Context, conceptUUID string, params content.ImplicitParams) (content.ConceptContent, *content.QueryProfile, error) {
+	result, err := dS.GetContentForConceptImplicitly(ctx, conceptUUID, params)
+	return result, testProfile(), err
+}
+
+func (dS dummyService) ConceptUUIDForIdentifier(_ context.Context, authority, identifier string) (string, error) {
+	if strings.EqualFold(authority, "TME") && identifier == testTMEIdentifier {
+		return testConceptID, nil
+	}
+	if strings.EqualFold(authority, "TME") && identifier == testAmbiguousTMEIdentifier {
+		return "", content.ErrAmbiguousIdentifier
+	}
+	return "", content.ErrConceptNotFound
+}
+
+func (dS dummyService) CanonicalUUID(_ context.Context, conceptUUID string) (string, error) {
+	if dS.backendErr != nil {
+		return "", dS.backendErr
+	}
+	return conceptUUID, nil
+}
+
+func (dS dummyService) GetContentForConcepts(ctx context.Context, queries []content.ConceptQuery) []content.ConceptQueryResult {
+	results := make([]content.ConceptQueryResult, 0, len(queries))
+	for _, query := range queries {
+		result, err := dS.GetContentForConcept(ctx, query.ConceptUUID, query.Params)
+		results = append(results, content.ConceptQueryResult{ConceptContent: result, Err: err})
+	}
+	return results
+}
+
+// CountContentForConcepts counts the content in contentIDList for every concept.
+func (dS dummyService) CountContentForConcepts(_ context.Context, conceptUUIDs []string, _ content.CountParams) (map[string]int, error) {
+	if dS.backendErr != nil {
+		return nil, dS.backendErr
+	}
+	counts := make(map[string]int, len(conceptUUIDs))
+	for _, conceptUUID := range conceptUUIDs {
+		counts[conceptUUID] = len(dS.contentIDList)
+	}
+	return counts, nil
+}
+
+// GetContentHistogram puts the content in contentIDList in the first bucket. The backend error is returned as is
+// so that tests can make the service fail with any of the errors of content.
+func (dS dummyService) GetContentHistogram(_ context.Context, _ string, params content.HistogramParams) ([]content.HistogramBucket, error) {
+	if dS.backendErr != nil {
+		return nil, dS.backendErr
+	}
+	from := time.Unix(params.FromDateEpoch, 0).UTC().Format(dateTimeLayout)
+	return []content.HistogramBucket{{Date: from, Count: len(dS.contentIDList)}}, nil
+}
+
+// GetTrendingConcepts returns a concept trending with the content in contentIDList for each type asked for.
+func (dS dummyService) GetTrendingConcepts(_ context.Context, params content.TrendingParams) ([]content.TrendingConcept, error) {
+	if dS.backendErr != nil {
+		return nil, dS.backendErr
+	}
+	concepts := make([]content.TrendingConcept, 0, len(params.ConceptTypes))
+	for _, conceptType := range params.ConceptTypes {
+		concepts = append(concepts, content.TrendingConcept{
+			ID:          content.ThingsPrefix + testConceptID,
+			PrefLabel:   "Test Concept",
+			Type:        conceptType,
+			RecentCount: len(dS.contentIDList),
+			Growth:      params.Baseline.Hours() / params.Window.Hours(),
+		})
+	}
+	return concepts, nil
+}
+
+// GetCooccurringConcepts returns anotherConceptID co-occurring in the content in contentIDList.
+func (dS dummyService) GetCooccurringConcepts(_ context.Context, _ string, params content.CooccurrenceParams) ([]content.CooccurringConcept, error) {
+	if dS.backendErr != nil {
+		return nil, dS.backendErr
+	}
+	concepts := []content.CooccurringConcept{}
+	if len(dS.contentIDList) > 0 && params.Limit > 0 {
+		concepts = append(concepts, content.CooccurringConcept{
+			ID:         content.ThingsPrefix + anotherConceptID,
+			PrefLabel:  "Another Concept",
+			Type:       "Organisation",
+			Count:      len(dS.contentIDList),
+			Similarity: 0.5,
+		})
+	}
+	return concepts, nil
+}
+
+// GetConceptExpansion expands the concept to itself and anotherConceptID, and to subsidiaryConceptID when subsidiaries are asked for.
+func (dS dummyService) GetConceptExpansion(_ context.Context, conceptUUID string, params content.ImplicitParams) ([]content.ExpandedConcept, error) {
+	if dS.backendErr != nil {
+		return nil, dS.backendErr
+	}
+	concepts := []content.ExpandedConcept{
+		{ID: content.ThingsPrefix + conceptUUID, PrefLabel: "Test Concept", Type: "Location", Relationships: []string{}},
+		{ID: content.ThingsPrefix + anotherConceptID, PrefLabel: "Another Concept", Type: "Location", Relationships: []string{"IS_PART_OF"}, Depth: 1},
+	}
+	if params.SubsidiaryDepth > 0 {
+		concepts = append(concepts, content.ExpandedConcept{ID: content.ThingsPrefix + subsidiaryConceptID, PrefLabel: "Subsidiary", Type: "Organisation", Relationships: []string{"SUB_ORGANISATION_OF"}, Depth: 1})
+	}
+	return concepts, nil
+}
+
+// mergedConceptService resolves every concept to canonicalUUID as if they had been concorded into it,
+// counting the content queries it runs.
+type mergedConceptService struct {
+	dummyService
+	canonicalUUID  string
+	contentQueries *int
+}
+
+func (ms mergedConceptService) CanonicalUUID(_ context.Context, _ string) (string, error) {
+	return ms.canonicalUUID, nil
+}
+
+func (ms mergedConceptService) GetContentForConcept(ctx context.Context, conceptUUID string, params content.RequestParams) (content.ConceptContent, error) {
+	*ms.contentQueries++
+	result, err := ms.dummyService.GetContentForConcept(ctx, conceptUUID, params)
+	result.CanonicalUUID = ms.canonicalUUID
+	return result, err
+}
+
+func (ms mergedConceptService) GetContentForConceptImplicitly(ctx context.Context, conceptUUID string, params content.ImplicitParams) (content.ConceptContent, error) {
+	*ms.contentQueries++
+	result, err := ms.dummyService.GetContentForConceptImplicitly(ctx, conceptUUID, params)
+	result.CanonicalUUID = ms.canonicalUUID
+	return result, err
+}
+
+// recordingService records the queries and filters it is asked to run.
+type recordingService struct {
+	dummyService
+	requestParams      *content.RequestParams
+	queries            *[]content.ConceptQuery
+	countParams        *content.CountParams
+	cooccurrenceParams *content.CooccurrenceParams
+	implicitParams     *content.ImplicitParams
+}
+
+func (rs recordingService) GetContentForConcept(ctx context.Context, conceptUUID string, params content.RequestParams) (content.ConceptContent, error) {
+	*rs.requestParams = params
+	return rs.dummyService.GetContentForConcept(ctx, conceptUUID, params)
+}
+
+func (rs recordingService) GetContentForConceptImplicitly(ctx context.Context, conceptUUID string, params content.ImplicitParams) (content.ConceptContent, error) {
+	*rs.implicitParams = params
+	return rs.dummyService.GetContentForConceptImplicitly(ctx, conceptUUID, params)
+}
+
+func (rs recordingService) GetContentForConcepts(ctx context.Context, queries []content.ConceptQuery) []content.ConceptQueryResult {
+	*rs.queries = append(*rs.queries, queries...)
+	return rs.dummyService.GetContentForConcepts(ctx, queries)
+}
+
+func (rs recordingService) CountContentForConcepts(ctx context.Context, conceptUUIDs []string, params content.CountParams) (map[string]int, error) {
+	*rs.countParams = params
+	return rs.dummyService.CountContentForConcepts(ctx, conceptUUIDs, params)
+}
+
+func (rs recordingService) GetCooccurringConcepts(ctx context.Context, conceptUUID string, params content.CooccurrenceParams) ([]content.CooccurringConcept, error) {
+	*rs.cooccurrenceParams = params
+	return rs.dummyService.GetCooccurringConcepts(ctx, conceptUUID, params)
+}
+
+func testConcept(conceptUUID string) *content.Concept {
+	return &content.Concept{ID: content.ThingsPrefix + conceptUUID, PrefLabel: "Test Concept", Type: "Person", ConcordedIDs: []string{content.ThingsPrefix + conceptUUID}}
+}
 
-	return cntList, nil
+func testProfile() *content.QueryProfile {
+	return &content.QueryProfile{Cypher: "MATCH (c:Content)", DBHits: 42, Rows: 1}
 }
 
 func (dS dummyService) CheckConnection() (string, error) {
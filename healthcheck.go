@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"time"
 
 	fthealth "github.com/Financial-Times/go-fthealth/v1_1"
+	"github.com/Financial-Times/go-logger/v2"
+	"github.com/Financial-Times/public-content-by-concept-api/v2/content"
 	"github.com/Financial-Times/service-status-go/gtg"
 )
 
@@ -15,6 +19,9 @@ type HealthcheckService struct {
 	AppName        string
 	AppDescription string
 	ConnChecker    ConnectionChecker
+	SchemaChecker  ConnectionChecker
+	// RequireIndexes makes GTG take the required indexes into account too.
+	RequireIndexes bool
 }
 
 func (h *HealthcheckService) HealthHandler() func(w http.ResponseWriter, r *http.Request) {
@@ -30,33 +37,78 @@ func (h *HealthcheckService) HealthHandler() func(w http.ResponseWriter, r *http
 	return fthealth.Handler(hc)
 }
 
+// GTG only takes connectivity into account, as missing indexes slow the service down rather than stop it serving,
+// unless indexes are required. Instances that started while Neo4j was unreachable then only become good to go once
+// the required indexes are found online.
 func (h *HealthcheckService) GTG() gtg.Status {
-	var statusChecker []gtg.StatusChecker
-	for _, c := range h.Checks() {
-		checkFunc := func() gtg.Status {
-			return gtgCheck(c.Checker)
-		}
-		statusChecker = append(statusChecker, checkFunc)
+	status := gtgCheck(h.connectivityCheck().Checker)
+	if !status.GoodToGo || !h.RequireIndexes {
+		return status
 	}
-	return gtg.FailFastParallelCheck(statusChecker)()
+	return gtgCheck(h.SchemaChecker)
 }
 
 func (h *HealthcheckService) Checks() []fthealth.Check {
 	return []fthealth.Check{
+		h.connectivityCheck(),
 		{
-			BusinessImpact:   "Cannot respond to API requests",
-			Name:             "Check connectivity to Neo4j",
+			BusinessImpact:   "API requests are served slowly",
+			Name:             "Check required Neo4j indexes",
 			PanicGuide:       "https://runbooks.ftops.tech/" + h.AppSystemCode,
-			Severity:         2,
-			TechnicalSummary: "Cannot connect to Neo4j instance",
-			Checker:          h.ConnChecker,
+			Severity:         3,
+			TechnicalSummary: "Indexes required by the content queries are missing or not online in Neo4j",
+			Checker:          h.SchemaChecker,
 		},
 	}
 }
 
+func (h *HealthcheckService) connectivityCheck() fthealth.Check {
+	return fthealth.Check{
+		BusinessImpact:   "Cannot respond to API requests",
+		Name:             "Check connectivity to Neo4j",
+		PanicGuide:       "https://runbooks.ftops.tech/" + h.AppSystemCode,
+		Severity:         2,
+		TechnicalSummary: "Cannot connect to Neo4j instance",
+		Checker:          h.ConnChecker,
+	}
+}
+
 func gtgCheck(handler func() (string, error)) gtg.Status {
 	if _, err := handler(); err != nil {
 		return gtg.Status{GoodToGo: false, Message: err.Error()}
 	}
 	return gtg.Status{GoodToGo: true}
 }
+
+type schemaChecker interface {
+	CheckSchema() (content.SchemaStatus, error)
+}
+
+// monitorSchema checks the required Neo4j indexes every interval until ctx is cancelled.
+func monitorSchema(ctx context.Context, checker schemaChecker, interval time.Duration, log *logger.UPPLogger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		_, _ = checkSchema(checker, log)
+	}
+}
+
+// checkSchema checks the required Neo4j indexes, warning when they cannot be checked or are not available.
+func checkSchema(checker schemaChecker, log *logger.UPPLogger) (content.SchemaStatus, error) {
+	status, err := checker.CheckSchema()
+	switch {
+	case errors.Is(err, content.ErrNotConnected):
+	case err != nil:
+		log.WithError(err).Warn("Could not check required Neo4j indexes")
+	case !status.OK():
+		log.Warnf("Required Neo4j indexes are not available: %s", status)
+	}
+	return status, err
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	fthealth "github.com/Financial-Times/go-fthealth/v1_1"
+	"github.com/Financial-Times/public-content-by-concept-api/v2/content"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthcheckService_GTG(t *testing.T) {
+	healthy := func() (string, error) { return "OK", nil }
+	unhealthy := func() (string, error) { return "", errors.New("required indexes have not been checked yet") }
+	notConnected := func() (string, error) { return "Could not connect to database!", errors.New("not connected to neo4j") }
+
+	tests := []struct {
+		testName        string
+		connChecker     ConnectionChecker
+		schemaChecker   ConnectionChecker
+		requireIndexes  bool
+		expectedGTG     bool
+		expectedMessage string
+	}{
+		{
+			testName:      "Connected",
+			connChecker:   healthy,
+			schemaChecker: healthy,
+			expectedGTG:   true,
+		},
+		{
+			testName:        "Not connected",
+			connChecker:     notConnected,
+			schemaChecker:   healthy,
+			expectedMessage: "not connected to neo4j",
+		},
+		{
+			testName:      "Indexes not available without requiring them",
+			connChecker:   healthy,
+			schemaChecker: unhealthy,
+			expectedGTG:   true,
+		},
+		{
+			testName:        "Indexes not available when required",
+			connChecker:     healthy,
+			schemaChecker:   unhealthy,
+			requireIndexes:  true,
+			expectedMessage: "required indexes have not been checked yet",
+		},
+		{
+			testName:       "Indexes online when required",
+			connChecker:    healthy,
+			schemaChecker:  healthy,
+			requireIndexes: true,
+			expectedGTG:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			hs := HealthcheckService{ConnChecker: test.connChecker, SchemaChecker: test.schemaChecker, RequireIndexes: test.requireIndexes}
+
+			status := hs.GTG()
+			assert.Equal(t, test.expectedGTG, status.GoodToGo)
+			assert.Equal(t, test.expectedMessage, status.Message)
+		})
+	}
+}
+
+func TestHealthcheckService_NotConnected(t *testing.T) {
+	service, err := content.NewContentByConceptService(nil, "http://localhost:8080/content")
+	require.NoError(t, err)
+	hs := HealthcheckService{
+		AppSystemCode:  "upp-public-content-by-concept-api",
+		AppName:        "public-content-by-concept-api",
+		ConnChecker:    service.CheckConnection,
+		SchemaChecker:  service.SchemaHealth,
+		RequireIndexes: true,
+	}
+
+	status := hs.GTG()
+	assert.False(t, status.GoodToGo)
+	assert.Equal(t, content.ErrNotConnected.Error(), status.Message)
+
+	rec := httptest.NewRecorder()
+	hs.HealthHandler()(rec, httptest.NewRequest(http.MethodGet, "/__health", nil))
+
+	var health fthealth.HealthResult
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &health))
+	assert.False(t, health.Ok)
+	if assert.Len(t, health.Checks, 2) {
+		for _, check := range health.Checks {
+			assert.False(t, check.Ok, "%s should fail while not connected", check.Name)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Financial-Times/go-logger/v2"
+	"github.com/Financial-Times/public-content-by-concept-api/v2/content"
+	transactionidutils "github.com/Financial-Times/transactionid-utils-go"
+)
+
+const defaultInterval = "day"
+
+// histogramParams are the query parameters accepted by the histogram endpoint.
+var histogramParams = []string{"isAnnotatedBy", "interval", "fromDate", "toDate", "publication", "strict"}
+
+type histogramResponse struct {
+	Interval string                    `json:"interval"`
+	Buckets  []content.HistogramBucket `json:"buckets"`
+}
+
+// GetContentHistogram returns how much content was published for the concept in each interval between fromDate and toDate.
+func (h *Handler) GetContentHistogram(w http.ResponseWriter, r *http.Request) {
+	transID := transactionidutils.GetTransactionIDFromRequest(r)
+	ctx := transactionidutils.TransactionAwareContext(r.Context(), transID)
+
+	logEntry := h.Log.WithTransactionID(transID)
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set(transactionidutils.TransactionIDHeader, transID)
+
+	m, err := url.ParseQuery(r.URL.RawQuery)
+	if err != nil {
+		logEntry.WithError(err).Error("Could not parse request url")
+		writeProblem(ctx, w, http.StatusBadRequest, "", "Could not parse the request query")
+		return
+	}
+	logEntry.Debugf("Request url is %s", r.URL.RawQuery)
+
+	strict, err := h.strictRequested(m, histogramParams)
+	if err != nil {
+		writeRequestError(ctx, w, err)
+		return
+	}
+
+	conceptURI := m.Get("isAnnotatedBy")
+	if conceptURI == "" {
+		writeProblem(ctx, w, http.StatusBadRequest, "isAnnotatedBy", "Missing or empty query parameter isAnnotatedBy. Expecting valid absolute concept URI.")
+		return
+	}
+
+	ref, err := parseConceptRef(conceptURI)
+	if err != nil {
+		writeRequestError(ctx, w, err)
+		return
+	}
+
+	params, err := h.extractHistogramParams(m, strict, logEntry)
+	if err != nil {
+		writeRequestError(ctx, w, err)
+		return
+	}
+
+	conceptUUID, err := h.resolveConceptRef(ctx, ref)
+	if err != nil {
+		if errors.Is(err, content.ErrConceptNotFound) {
+			msg := fmt.Sprintf("No concept found with identifier %s", conceptURI)
+			logEntry.Debugf(msg)
+			writeConceptNotFound(ctx, w, msg)
+			return
+		}
+		if errors.Is(err, content.ErrAmbiguousIdentifier) {
+			msg := fmt.Sprintf("More than one concept found with identifier %s", conceptURI)
+			logEntry.Debugf(msg)
+			writeProblem(ctx, w, http.StatusConflict, "isAnnotatedBy", msg)
+			return
+		}
+
+		msg := fmt.Sprintf("Backend error resolving concept with identifier %s", conceptURI)
+		logEntry.WithError(err).Error(msg)
+		writeProblem(ctx, w, http.StatusServiceUnavailable, "", msg)
+		return
+	}
+	logEntry = logEntry.WithUUID(conceptUUID)
+
+	dbStart := time.Now()
+	buckets, err := h.ContentService.GetContentHistogram(ctx, conceptUUID, params)
+	recordTiming(ctx, timingDB, dbStart)
+	switch {
+	case errors.Is(err, content.ErrTooManyBuckets):
+		writeProblem(ctx, w, http.StatusBadRequest, "interval", fmt.Sprintf("%s between %s and %s", err, m.Get("fromDate"), m.Get("toDate")))
+		return
+	case errors.Is(err, content.ErrConceptNotFound):
+		msg := fmt.Sprintf("No concept found with uuid %s", conceptUUID)
+		logEntry.Debugf(msg)
+		writeConceptNotFound(ctx, w, msg)
+		return
+	case err != nil:
+		msg := fmt.Sprintf("Backend error returning content histogram for concept with uuid %s", conceptUUID)
+		logEntry.WithError(err).Error(msg)
+		writeProblem(ctx, w, http.StatusServiceUnavailable, "", msg)
+		return
+	}
+
+	encoded, err := encodeJSON(ctx, histogramResponse{Interval: params.Interval, Buckets: buckets})
+	if err != nil {
+		msg := fmt.Sprintf("Error parsing returned content histogram for concept with uuid %s", conceptUUID)
+		logEntry.WithError(err).Error(msg)
+		writeProblem(ctx, w, http.StatusInternalServerError, "", msg)
+		return
+	}
+
+	w.Header().Set("Cache-Control", h.CacheControlHeader)
+	writeServerTiming(ctx, w)
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(encoded); err != nil {
+		logEntry.WithError(err).Errorf("Error writing content histogram for concept with uuid %s", conceptUUID)
+	}
+}
+
+// extractHistogramParams validates the interval and the dates, which are required and must be in order.
+func (h *Handler) extractHistogramParams(val url.Values, strict bool, log *logger.LogEntry) (content.HistogramParams, error) {
+	interval := val.Get("interval")
+	if interval == "" {
+		interval = defaultInterval
+	}
+	if !slices.Contains(content.Intervals(), interval) {
+		return content.HistogramParams{}, newParamError("interval", "%s is not a supported interval. Expecting one of: %s", interval, strings.Join(content.Intervals(), ", "))
+	}
+
+	for _, param := range []string{"fromDate", "toDate"} {
+		if val.Get(param) == "" {
+			return content.HistogramParams{}, newParamError(param, "Missing or empty query parameter %s. Expecting a date in YYYY-MM-DD format.", param)
+		}
+	}
+
+	fromDateEpoch, toDateEpoch, _, err := extractDates(val, strict, log)
+	if err != nil {
+		return content.HistogramParams{}, err
+	}
+	if fromDateEpoch >= toDateEpoch {
+		return content.HistogramParams{}, newParamError("fromDate", "From date %s should be before to date %s", val.Get("fromDate"), val.Get("toDate"))
+	}
+
+	publication, err := extractPublication(val, log)
+	if err != nil {
+		return content.HistogramParams{}, err
+	}
+
+	return content.HistogramParams{
+		Interval:      interval,
+		FromDateEpoch: fromDateEpoch,
+		ToDateEpoch:   toDateEpoch,
+		Publication:   publication,
+	}, nil
+}
@@ -4,11 +4,15 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/Financial-Times/go-logger/v2"
 	opa "github.com/Financial-Times/opa-client-go"
+	"github.com/Financial-Times/public-content-by-concept-api/v2/content"
 	"github.com/Financial-Times/public-content-by-concept-api/v2/policy"
 	cli "github.com/jawher/mow.cli"
 )
@@ -38,6 +42,66 @@ func main() {
 		Desc:   "neo4j endpoint URL",
 		EnvVar: "NEO_URL",
 	})
+	neoUsername := app.String(cli.StringOpt{
+		Name:   "neo-username",
+		Value:  "",
+		Desc:   "neo4j username. Leave empty to connect without authentication",
+		EnvVar: "NEO_USERNAME",
+	})
+	neoPassword := app.String(cli.StringOpt{
+		Name:   "neo-password",
+		Value:  "",
+		Desc:   "neo4j password",
+		EnvVar: "NEO_PASSWORD",
+	})
+	neoPasswordFile := app.String(cli.StringOpt{
+		Name:   "neo-password-file",
+		Value:  "",
+		Desc:   "Path to a file containing the neo4j password, e.g. a mounted secret. Takes precedence over neo-password",
+		EnvVar: "NEO_PASSWORD_FILE",
+	})
+	neoDatabase := app.String(cli.StringOpt{
+		Name:   "neo-database",
+		Value:  "",
+		Desc:   "Name of the neo4j database to query. Leave empty to use the server's default database",
+		EnvVar: "NEO_DATABASE",
+	})
+	neoTLSCAFile := app.String(cli.StringOpt{
+		Name:   "neo-tls-ca-file",
+		Value:  "",
+		Desc:   "Path to a PEM file with the CA certificates used to verify the neo4j server. Requires a neo4j+s or bolt+s URL",
+		EnvVar: "NEO_TLS_CA_FILE",
+	})
+	neoReconnectInterval := app.String(cli.StringOpt{
+		Name:   "neo-reconnect-interval",
+		Value:  "10s",
+		Desc:   "How often to retry connecting to neo4j when it is unreachable at start up",
+		EnvVar: "NEO_RECONNECT_INTERVAL",
+	})
+	neoMaxPoolSize := app.Int(cli.IntOpt{
+		Name:   "neo-max-connection-pool-size",
+		Value:  100,
+		Desc:   "Maximum number of connections the neo4j driver keeps open",
+		EnvVar: "NEO_MAX_CONNECTION_POOL_SIZE",
+	})
+	schemaCheckInterval := app.String(cli.StringOpt{
+		Name:   "neo-schema-check-interval",
+		Value:  "5m",
+		Desc:   "How often to check that the neo4j indexes required by the queries exist and are online",
+		EnvVar: "NEO_SCHEMA_CHECK_INTERVAL",
+	})
+	requireIndexes := app.Bool(cli.BoolOpt{
+		Name:   "require-neo-indexes",
+		Value:  false,
+		Desc:   "Refuse to start when the neo4j indexes required by the queries are missing, not online or cannot be checked. When neo4j is unreachable at start up, the service is not good to go until they are online",
+		EnvVar: "REQUIRE_NEO_INDEXES",
+	})
+	slowQueryThreshold := app.String(cli.StringOpt{
+		Name:   "slow-query-threshold",
+		Value:  "1s",
+		Desc:   "neo4j queries taking longer than this are logged as warnings. Set to 0 to disable",
+		EnvVar: "SLOW_QUERY_THRESHOLD",
+	})
 	port := app.String(cli.StringOpt{
 		Name:   "port",
 		Value:  "8080",
@@ -56,6 +120,90 @@ func main() {
 		EnvVar: "RECORD_HTTP_METRICS",
 		Value:  false,
 	})
+	queryDebug := app.Bool(cli.BoolOpt{
+		Name:   "enable-query-debug",
+		Desc:   "allow admin requests, i.e. ones not coming through the API Gateway, to get the neo4j query profile with debug=profile",
+		EnvVar: "ENABLE_QUERY_DEBUG",
+		Value:  false,
+	})
+	redirectToCanonical := app.Bool(cli.BoolOpt{
+		Name:   "redirect-to-canonical",
+		Desc:   "answer requests for concepts concorded into another canonical concept with a 301 to the canonical concept instead of its content",
+		EnvVar: "REDIRECT_TO_CANONICAL",
+		Value:  false,
+	})
+	strictValidation := app.Bool(cli.BoolOpt{
+		Name:   "strict-validation",
+		Desc:   "reject requests with unknown query parameters or values that cannot be parsed or are out of range, instead of falling back to defaults. Requests can also ask for it with strict=true",
+		EnvVar: "STRICT_VALIDATION",
+		Value:  false,
+	})
+	maxLimit := app.Int(cli.IntOpt{
+		Name:   "max-limit",
+		Value:  1000,
+		Desc:   "Highest limit accepted in strict mode. Set to 0 for no cap",
+		EnvVar: "MAX_LIMIT",
+	})
+	maxPageDepth := app.Int(cli.IntOpt{
+		Name:   "max-page-depth",
+		Value:  100,
+		Desc:   "Highest page accepted in strict mode. Set to 0 for no cap",
+		EnvVar: "MAX_PAGE_DEPTH",
+	})
+	maxBatchSize := app.Int(cli.IntOpt{
+		Name:   "max-batch-size",
+		Value:  50,
+		Desc:   "Highest number of concepts a POST /content/batch or /content/count request can ask for. Set to 0 for no cap",
+		EnvVar: "MAX_BATCH_SIZE",
+	})
+	maxSubsidiaryDepth := app.Int(cli.IntOpt{
+		Name:   "max-subsidiary-depth",
+		Value:  3,
+		Desc:   "Highest subsidiaryDepth accepted by /content/{uuid}/implicitly. Set to 0 to disable subsidiary expansion",
+		EnvVar: "MAX_SUBSIDIARY_DEPTH",
+	})
+	relevancePredicateWeights := app.Strings(cli.StringsOpt{
+		Name:   "relevance-predicate-weights",
+		Value:  []string{"about=3", "hasDisplayTag=2", "mentions=1"},
+		Desc:   "Weights of the annotation predicates when content is sorted by relevance, as predicate=weight. Predicates left out weigh nothing",
+		EnvVar: "RELEVANCE_PREDICATE_WEIGHTS",
+	})
+	relevanceLeafWeight := app.String(cli.StringOpt{
+		Name:   "relevance-leaf-weight",
+		Value:  "1",
+		Desc:   "Weight of each of the concorded concepts annotating the content when content is sorted by relevance",
+		EnvVar: "RELEVANCE_LEAF_WEIGHT",
+	})
+	relevanceHalfLife := app.String(cli.StringOpt{
+		Name:   "relevance-half-life",
+		Value:  "168h",
+		Desc:   "How long it takes for the relevance of content to halve when content is sorted by relevance",
+		EnvVar: "RELEVANCE_HALF_LIFE",
+	})
+	batchConcurrency := app.Int(cli.IntOpt{
+		Name:   "batch-concurrency",
+		Value:  8,
+		Desc:   "How many of the neo4j queries of a batch request run at the same time",
+		EnvVar: "BATCH_CONCURRENCY",
+	})
+	serverTiming := app.Bool(cli.BoolOpt{
+		Name:   "server-timing",
+		Desc:   "add a Server-Timing header with the time spent on the policy decision, the neo4j query and encoding to content responses",
+		EnvVar: "SERVER_TIMING",
+		Value:  false,
+	})
+	tracingExporter := app.String(cli.StringOpt{
+		Name:   "tracing-exporter",
+		Value:  "none",
+		Desc:   "Where to export OpenTelemetry traces to: none, otlp or stdout",
+		EnvVar: "TRACING_EXPORTER",
+	})
+	tracingOTLPEndpoint := app.String(cli.StringOpt{
+		Name:   "tracing-otlp-endpoint",
+		Value:  "http://localhost:4318",
+		Desc:   "URL of the OTLP/HTTP collector traces are sent to when tracing-exporter is otlp",
+		EnvVar: "TRACING_OTLP_ENDPOINT",
+	})
 	logLevel := app.String(cli.StringOpt{
 		Name:   "logLevel",
 		Value:  "INFO",
@@ -80,6 +228,18 @@ func main() {
 		Desc:   "API Gateway URL used when building the thing ID url in the response, in the format scheme://host",
 		EnvVar: "PUBLIC_API_URL",
 	})
+	ftURL := app.String(cli.StringOpt{
+		Name:   "ftURL",
+		Value:  "http://www.ft.com",
+		Desc:   "FT's URL used when building the ID url in the response, in the format scheme://host",
+		EnvVar: "FT_URL",
+	})
+	trustedForwardedHosts := app.Strings(cli.StringsOpt{
+		Name:   "trustedForwardedHosts",
+		Value:  []string{},
+		Desc:   "Hosts of the gateways trusted to set X-Forwarded-Host and X-Forwarded-Proto. The apiUrl in the response of their requests is built from these headers instead of publicAPIURL, and responses vary by them",
+		EnvVar: "TRUSTED_FORWARDED_HOSTS",
+	})
 
 	openPolicyAgentURL := app.String(cli.StringOpt{
 		Name:   "openPolicyAgentURL",
@@ -89,7 +249,7 @@ func main() {
 	})
 
 	log := logger.NewUPPLogger(*appName, *logLevel)
-	dbLog := logger.NewUPPLogger(fmt.Sprintf("%s %s", *appName, "cmneo4j-driver"), *dbDriverLogLevel)
+	dbLog := logger.NewUPPLogger(fmt.Sprintf("%s %s", *appName, "neo4j-driver"), *dbDriverLogLevel)
 
 	app.Action = func() {
 
@@ -98,15 +258,59 @@ func main() {
 			log.WithError(err).Fatal("Failed to parse cache duration value")
 		}
 
+		reconnectInterval, err := time.ParseDuration(*neoReconnectInterval)
+		if err != nil || reconnectInterval <= 0 {
+			log.WithError(err).Fatal("Failed to parse neo4j reconnect interval value")
+		}
+
+		schemaInterval, err := time.ParseDuration(*schemaCheckInterval)
+		if err != nil || schemaInterval <= 0 {
+			log.WithError(err).Fatal("Failed to parse neo4j schema check interval value")
+		}
+
+		slowQuery, err := time.ParseDuration(*slowQueryThreshold)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to parse slow query threshold value")
+		}
+
+		relevanceWeights, err := parseRelevanceWeights(*relevancePredicateWeights, *relevanceLeafWeight, *relevanceHalfLife)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to parse relevance weights")
+		}
+
 		config := ServerConfig{
-			Port:           *port,
-			APIYMLPath:     *apiYml,
-			CacheTime:      duration,
-			RecordMetrics:  *recordMetrics,
-			AppSystemCode:  *appSystemCode,
-			AppName:        *appName,
-			AppDescription: appDescription,
-			NeoURL:         *neoURL,
+			Port:                     *port,
+			APIYMLPath:               *apiYml,
+			CacheTime:                duration,
+			RecordMetrics:            *recordMetrics,
+			QueryDebugEnabled:        *queryDebug,
+			ServerTiming:             *serverTiming,
+			RedirectToCanonical:      *redirectToCanonical,
+			FTURL:                    *ftURL,
+			TrustedForwardedHosts:    *trustedForwardedHosts,
+			MaxBatchSize:             *maxBatchSize,
+			BatchConcurrency:         *batchConcurrency,
+			StrictValidation:         *strictValidation,
+			MaxLimit:                 *maxLimit,
+			MaxPageDepth:             *maxPageDepth,
+			MaxSubsidiaryDepth:       *maxSubsidiaryDepth,
+			RelevanceWeights:         relevanceWeights,
+			AppSystemCode:            *appSystemCode,
+			AppName:                  *appName,
+			AppDescription:           appDescription,
+			NeoURL:                   *neoURL,
+			NeoUsername:              *neoUsername,
+			NeoPassword:              *neoPassword,
+			NeoPasswordFile:          *neoPasswordFile,
+			NeoDatabase:              *neoDatabase,
+			NeoTLSCAFile:             *neoTLSCAFile,
+			NeoReconnectInterval:     reconnectInterval,
+			NeoMaxConnectionPoolSize: *neoMaxPoolSize,
+			SchemaCheckInterval:      schemaInterval,
+			RequireIndexes:           *requireIndexes,
+			SlowQueryThreshold:       slowQuery,
+			TracingExporter:          *tracingExporter,
+			TracingOTLPEndpoint:      *tracingOTLPEndpoint,
 		}
 
 		paths := map[string]string{
@@ -128,6 +332,36 @@ func main() {
 	}
 }
 
+// parseRelevanceWeights parses the predicate=weight pairs, the leaf weight and the half-life of the relevance score.
+func parseRelevanceWeights(predicateWeights []string, leafWeight, halfLife string) (content.RelevanceWeights, error) {
+	weights := content.RelevanceWeights{Predicates: make(map[string]float64, len(predicateWeights))}
+	for _, pair := range predicateWeights {
+		predicate, value, _ := strings.Cut(pair, "=")
+		if !slices.Contains(content.Predicates(), predicate) {
+			return content.RelevanceWeights{}, fmt.Errorf("%w: %s", content.ErrUnknownPredicate, predicate)
+		}
+		weight, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return content.RelevanceWeights{}, fmt.Errorf("weight of predicate %s: %w", predicate, err)
+		}
+		weights.Predicates[predicate] = weight
+	}
+
+	var err error
+	weights.Leaves, err = strconv.ParseFloat(leafWeight, 64)
+	if err != nil {
+		return content.RelevanceWeights{}, fmt.Errorf("leaf weight: %w", err)
+	}
+	weights.HalfLife, err = time.ParseDuration(halfLife)
+	if err != nil {
+		return content.RelevanceWeights{}, fmt.Errorf("half-life: %w", err)
+	}
+	if weights.HalfLife <= 0 {
+		return content.RelevanceWeights{}, fmt.Errorf("half-life should be positive, got %s", halfLife)
+	}
+	return weights, nil
+}
+
 func waitForSignal() {
 	ch := make(chan os.Signal, 1)
 	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM)
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rcrowley/go-metrics"
+)
+
+const (
+	metricsPath      = "/metrics"
+	metricsNamespace = "public_content_by_concept_api"
+)
+
+// Metrics holds the Prometheus metrics exposed on /metrics.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requestDuration *prometheus.HistogramVec
+	queryDuration   *prometheus.HistogramVec
+	queryRows       *prometheus.HistogramVec
+	queriesInFlight prometheus.Gauge
+	connectionsUsed prometheus.Gauge
+	connectionWait  prometheus.Histogram
+	opaDuration     *prometheus.HistogramVec
+}
+
+// NewMetrics registers the service metrics. connected reports whether there is a Neo4j driver to query with.
+func NewMetrics(connected func() bool) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of HTTP requests by route, method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "neo4j_query_duration_seconds",
+			Help:      "Duration of neo4j queries by query type and outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"query_type", "outcome"}),
+		queryRows: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "neo4j_query_rows",
+			Help:      "Number of rows returned by neo4j queries by query type.",
+			Buckets:   prometheus.ExponentialBuckets(1, 4, 8),
+		}, []string{"query_type"}),
+		queriesInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "neo4j_queries_in_flight",
+			Help:      "Number of neo4j queries the service is currently running.",
+		}),
+		connectionsUsed: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "neo4j_connections_in_use",
+			Help:      "Number of connections the service queries currently hold from the neo4j driver pool.",
+		}),
+		connectionWait: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "neo4j_connection_acquisition_seconds",
+			Help:      "Time neo4j transactions waited to get a connection from the driver pool and begin, including the backoff of retries.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		}),
+		opaDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "opa_request_duration_seconds",
+			Help:      "Duration of policy decisions made by the open policy agent by outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"outcome"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requestDuration,
+		m.queryDuration,
+		m.queryRows,
+		m.queriesInFlight,
+		m.connectionsUsed,
+		m.connectionWait,
+		m.opaDuration,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "neo4j_connected",
+			Help:      "Whether the service has a neo4j driver to run queries with (1) or is running in degraded mode (0).",
+		}, func() float64 {
+			if connected() {
+				return 1
+			}
+			return 0
+		}),
+	)
+	return m
+}
+
+// Handler serves the registered metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// InstrumentRoutes is a mux middleware recording the duration of requests by route template.
+func (m *Metrics) InstrumentRoutes(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		route := "unknown"
+		if current := mux.CurrentRoute(r); current != nil {
+			if tmpl, err := current.GetPathTemplate(); err == nil {
+				route = tmpl
+			}
+		}
+		m.requestDuration.WithLabelValues(route, r.Method, strconv.Itoa(rec.status)).Observe(time.Since(start).Seconds())
+	})
+}
+
+// InstrumentPolicy records how long the policy middleware takes to decide whether a request is allowed,
+// i.e. the time until it hands the request on or answers it itself.
+func (m *Metrics) InstrumentPolicy(policyMiddleware mux.MiddlewareFunc) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			decided := false
+			allowed := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				decided = true
+				m.opaDuration.WithLabelValues("allowed").Observe(time.Since(start).Seconds())
+				next.ServeHTTP(w, r)
+			})
+
+			policyMiddleware(allowed).ServeHTTP(w, r)
+			if !decided {
+				m.opaDuration.WithLabelValues("rejected").Observe(time.Since(start).Seconds())
+			}
+		})
+	}
+}
+
+// QueryStarted implements content.QueryObserver.
+func (m *Metrics) QueryStarted(queryType string) func(rows int, err error) {
+	start := time.Now()
+	m.queriesInFlight.Inc()
+	return func(rows int, err error) {
+		m.queriesInFlight.Dec()
+
+		outcome := "success"
+		if err != nil {
+			outcome = "error"
+		}
+		m.queryDuration.WithLabelValues(queryType, outcome).Observe(time.Since(start).Seconds())
+		m.queryRows.WithLabelValues(queryType).Observe(float64(rows))
+	}
+}
+
+// ConnectionAcquired implements content.PoolObserver.
+func (m *Metrics) ConnectionAcquired(wait time.Duration) func() {
+	m.connectionWait.Observe(wait.Seconds())
+	m.connectionsUsed.Inc()
+	return m.connectionsUsed.Dec
+}
+
+// RegisterHandlerMetrics exposes the timers the http-handlers-go metrics handler records in registry,
+// one per HTTP method, as summaries of the request durations.
+func (m *Metrics) RegisterHandlerMetrics(registry metrics.Registry) {
+	m.registry.MustRegister(handlerMetricsCollector{registry: registry})
+}
+
+var handlerDurationDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(metricsNamespace, "", "http_handler_duration_seconds"),
+	"Duration of requests to the service handlers by HTTP method, as recorded with --record-http-metrics.",
+	[]string{"method"}, nil,
+)
+
+// handlerMetricsCollector collects the go-metrics timers of registry on every scrape.
+type handlerMetricsCollector struct {
+	registry metrics.Registry
+}
+
+func (c handlerMetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- handlerDurationDesc
+}
+
+func (c handlerMetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	quantiles := []float64{0.5, 0.9, 0.99}
+	c.registry.Each(func(name string, metric interface{}) {
+		timer, ok := metric.(metrics.Timer)
+		if !ok {
+			return
+		}
+		snapshot := timer.Snapshot()
+		values := snapshot.Percentiles(quantiles)
+		summary := make(map[float64]float64, len(quantiles))
+		for i, q := range quantiles {
+			summary[q] = time.Duration(values[i]).Seconds()
+		}
+		ch <- prometheus.MustNewConstSummary(handlerDurationDesc, uint64(snapshot.Count()),
+			time.Duration(snapshot.Sum()).Seconds(), summary, name)
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
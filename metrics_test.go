@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_Scrape(t *testing.T) {
+	m := NewMetrics(func() bool { return true })
+	handlerRegistry := metrics.NewRegistry()
+	m.RegisterHandlerMetrics(handlerRegistry)
+	metrics.GetOrRegisterTimer(http.MethodGet, handlerRegistry).Update(250 * time.Millisecond)
+
+	router := mux.NewRouter()
+	router.Use(m.InstrumentRoutes)
+	router.HandleFunc("/content/{conceptUUID}/implicitly", func(w http.ResponseWriter, r *http.Request) {
+		release := m.ConnectionAcquired(20 * time.Millisecond)
+		m.QueryStarted("implicit-content")(3, nil)
+		release()
+		w.WriteHeader(http.StatusOK)
+	}).Methods(http.MethodGet)
+	router.HandleFunc("/content/count", func(w http.ResponseWriter, r *http.Request) {
+		m.QueryStarted("content-count")(0, errors.New("connection refused"))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}).Methods(http.MethodGet)
+	router.Handle(metricsPath, m.Handler()).Methods(http.MethodGet)
+
+	// a connection held by a query still running at the time of the scrape
+	defer m.ConnectionAcquired(time.Second)()
+
+	for _, target := range []string{"/content/" + testConceptID + "/implicitly", "/content/" + anotherConceptID + "/implicitly", "/content/count"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, metricsPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	scraped := string(body)
+
+	for _, expected := range []string{
+		`public_content_by_concept_api_http_request_duration_seconds_count{method="GET",route="/content/{conceptUUID}/implicitly",status="200"} 2`,
+		`public_content_by_concept_api_http_request_duration_seconds_count{method="GET",route="/content/count",status="503"} 1`,
+		`public_content_by_concept_api_neo4j_query_duration_seconds_count{outcome="success",query_type="implicit-content"} 2`,
+		`public_content_by_concept_api_neo4j_query_duration_seconds_count{outcome="error",query_type="content-count"} 1`,
+		`public_content_by_concept_api_neo4j_query_rows_sum{query_type="implicit-content"} 6`,
+		`public_content_by_concept_api_neo4j_query_rows_bucket{query_type="content-count",le="1"} 1`,
+		`public_content_by_concept_api_neo4j_queries_in_flight 0`,
+		`public_content_by_concept_api_neo4j_connected 1`,
+		`public_content_by_concept_api_neo4j_connections_in_use 1`,
+		`public_content_by_concept_api_neo4j_connection_acquisition_seconds_count 3`,
+		`public_content_by_concept_api_neo4j_connection_acquisition_seconds_bucket{le="0.025"} 2`,
+		`public_content_by_concept_api_http_handler_duration_seconds_count{method="GET"} 1`,
+		`public_content_by_concept_api_http_handler_duration_seconds_sum{method="GET"} 0.25`,
+	} {
+		assert.Contains(t, scraped, expected)
+	}
+	assert.NotContains(t, scraped, testConceptID, "Requests should be labelled by route template")
+}
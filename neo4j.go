@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Financial-Times/go-logger/v2"
+	"github.com/Financial-Times/public-content-by-concept-api/v2/content"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// neoConnector creates Neo4j drivers from the connection options in the server config
+// and owns the lifecycle of the driver it has connected.
+type neoConnector struct {
+	url         string
+	auth        neo4j.AuthToken
+	configurers []func(*neo4j.Config)
+	database    string
+	log         *logger.UPPLogger
+	// newDriver creates a driver that has reached Neo4j, newVerifiedDriver unless replaced in tests.
+	newDriver func(uri string, auth neo4j.AuthToken, database string, configurers ...func(*neo4j.Config)) (*content.Driver, error)
+
+	mu     sync.Mutex
+	driver *content.Driver
+	closed bool
+}
+
+// newNeoConnector validates the Neo4j connection options. It does not connect to the database.
+func newNeoConnector(config ServerConfig, log *logger.UPPLogger) (*neoConnector, error) {
+	auth, err := neoAuth(config)
+	if err != nil {
+		return nil, err
+	}
+
+	configurers := []func(*neo4j.Config){func(c *neo4j.Config) {
+		c.Log = driverLogger{log: log}
+	}}
+	if config.NeoTLSCAFile != "" {
+		if !isEncryptedNeoURL(config.NeoURL) {
+			return nil, fmt.Errorf("neo4j TLS CA file requires an encrypted URL scheme (neo4j+s or bolt+s), got %s", config.NeoURL)
+		}
+		rootCAs, err := loadCertPool(config.NeoTLSCAFile)
+		if err != nil {
+			return nil, err
+		}
+		configurers = append(configurers, func(c *neo4j.Config) {
+			c.RootCAs = rootCAs
+		})
+	}
+
+	if config.NeoMaxConnectionPoolSize > 0 {
+		configurers = append(configurers, func(c *neo4j.Config) {
+			c.MaxConnectionPoolSize = config.NeoMaxConnectionPoolSize
+		})
+	}
+
+	return &neoConnector{
+		url:         config.NeoURL,
+		auth:        auth,
+		configurers: configurers,
+		database:    config.NeoDatabase,
+		log:         log,
+		newDriver:   newVerifiedDriver,
+	}, nil
+}
+
+// connect creates a driver and verifies it can reach Neo4j.
+func (c *neoConnector) connect() (*content.Driver, error) {
+	driver, err := c.newDriver(c.url, c.auth, c.database, c.configurers...)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		_ = driver.Close()
+		return nil, errors.New("neo4j connector is closed")
+	}
+	c.driver = driver
+	return driver, nil
+}
+
+// newVerifiedDriver creates a driver and verifies it can reach Neo4j, as the driver only connects once used.
+func newVerifiedDriver(uri string, auth neo4j.AuthToken, database string, configurers ...func(*neo4j.Config)) (*content.Driver, error) {
+	driver, err := content.NewDriver(uri, auth, database, configurers...)
+	if err != nil {
+		return nil, err
+	}
+	if err = driver.VerifyConnectivity(); err != nil {
+		_ = driver.Close()
+		return nil, err
+	}
+	return driver, nil
+}
+
+// reconnect retries connect every interval until it succeeds or ctx is cancelled.
+// The connected driver is passed to onConnect.
+func (c *neoConnector) reconnect(ctx context.Context, interval time.Duration, onConnect func(*content.Driver), log *logger.UPPLogger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		driver, err := c.connect()
+		if err != nil {
+			log.WithError(err).Warnf("Could not connect to Neo4j, retrying in %s", interval)
+			continue
+		}
+
+		log.Info("Connected to Neo4j")
+		onConnect(driver)
+		return
+	}
+}
+
+// close closes the connected driver, if any, and prevents further connections.
+func (c *neoConnector) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	if c.driver == nil {
+		return nil
+	}
+	return c.driver.Close()
+}
+
+func neoAuth(config ServerConfig) (neo4j.AuthToken, error) {
+	password := config.NeoPassword
+	if config.NeoPasswordFile != "" {
+		b, err := os.ReadFile(config.NeoPasswordFile)
+		if err != nil {
+			return neo4j.AuthToken{}, fmt.Errorf("reading neo4j password file: %w", err)
+		}
+		password = strings.TrimSpace(string(b))
+	}
+
+	if config.NeoUsername == "" {
+		if password != "" {
+			return neo4j.AuthToken{}, errors.New("neo4j password provided without a username")
+		}
+		return neo4j.NoAuth(), nil
+	}
+	return neo4j.BasicAuth(config.NeoUsername, password, ""), nil
+}
+
+func loadCertPool(file string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading neo4j TLS CA file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no valid PEM certificates found in %s", file)
+	}
+	return pool, nil
+}
+
+func isEncryptedNeoURL(uri string) bool {
+	return strings.HasPrefix(uri, "neo4j+s") || strings.HasPrefix(uri, "bolt+s")
+}
+
+// driverLogger writes the logs of the neo4j driver to the driver log, along with the driver component logging.
+type driverLogger struct {
+	log *logger.UPPLogger
+}
+
+func (l driverLogger) Error(name, id string, err error) {
+	l.log.WithField("component", name+"-"+id).WithError(err).Error("Neo4j driver error")
+}
+
+func (l driverLogger) Warnf(name, id, msg string, args ...interface{}) {
+	l.log.WithField("component", name+"-"+id).Warnf(msg, args...)
+}
+
+func (l driverLogger) Infof(name, id, msg string, args ...interface{}) {
+	l.log.WithField("component", name+"-"+id).Infof(msg, args...)
+}
+
+func (l driverLogger) Debugf(name, id, msg string, args ...interface{}) {
+	l.log.WithField("component", name+"-"+id).Debugf(msg, args...)
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/go-logger/v2"
+	"github.com/Financial-Times/public-content-by-concept-api/v2/content"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNeoAuth(t *testing.T) {
+	dir := t.TempDir()
+	passwordFile := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("file-secret\n"), 0o600))
+
+	tests := []struct {
+		testName      string
+		config        ServerConfig
+		expectedAuth  neo4j.AuthToken
+		expectedError string
+	}{
+		{
+			testName:     "No credentials",
+			expectedAuth: neo4j.NoAuth(),
+		},
+		{
+			testName:     "Password flag",
+			config:       ServerConfig{NeoUsername: "neo4j", NeoPassword: "flag-secret"},
+			expectedAuth: neo4j.BasicAuth("neo4j", "flag-secret", ""),
+		},
+		{
+			testName:     "Password file over the flag",
+			config:       ServerConfig{NeoUsername: "neo4j", NeoPassword: "flag-secret", NeoPasswordFile: passwordFile},
+			expectedAuth: neo4j.BasicAuth("neo4j", "file-secret", ""),
+		},
+		{
+			testName:      "Missing password file",
+			config:        ServerConfig{NeoUsername: "neo4j", NeoPasswordFile: filepath.Join(dir, "missing")},
+			expectedError: "reading neo4j password file",
+		},
+		{
+			testName:      "Password without a username",
+			config:        ServerConfig{NeoPassword: "flag-secret"},
+			expectedError: "neo4j password provided without a username",
+		},
+		{
+			testName:      "Password file without a username",
+			config:        ServerConfig{NeoPasswordFile: passwordFile},
+			expectedError: "neo4j password provided without a username",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			auth, err := neoAuth(test.config)
+			if test.expectedError != "" {
+				assert.ErrorContains(t, err, test.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedAuth, auth)
+		})
+	}
+}
+
+func TestLoadCertPool(t *testing.T) {
+	dir := t.TempDir()
+	invalidFile := filepath.Join(dir, "invalid.pem")
+	require.NoError(t, os.WriteFile(invalidFile, []byte("not a certificate"), 0o600))
+
+	tests := []struct {
+		testName      string
+		file          string
+		expectedError string
+	}{
+		{
+			testName: "Valid CA file",
+			file:     writeTestCA(t, dir),
+		},
+		{
+			testName:      "Missing CA file",
+			file:          filepath.Join(dir, "missing.pem"),
+			expectedError: "reading neo4j TLS CA file",
+		},
+		{
+			testName:      "Invalid CA file",
+			file:          invalidFile,
+			expectedError: "no valid PEM certificates found in " + invalidFile,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			pool, err := loadCertPool(test.file)
+			if test.expectedError != "" {
+				assert.ErrorContains(t, err, test.expectedError)
+				assert.Nil(t, pool)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, pool)
+		})
+	}
+}
+
+func TestIsEncryptedNeoURL(t *testing.T) {
+	tests := map[string]bool{
+		"neo4j+s://neo4j.example.com:7687": true,
+		"bolt+s://neo4j.example.com:7687":  true,
+		"neo4j://neo4j.example.com:7687":   false,
+		"bolt://neo4j.example.com:7687":    false,
+		"http://neo4j.example.com:7474":    false,
+	}
+
+	for uri, expected := range tests {
+		assert.Equal(t, expected, isEncryptedNeoURL(uri), "Wrong encryption for %s", uri)
+	}
+}
+
+func TestNewNeoConnector_TLSCAFile(t *testing.T) {
+	log := logger.NewUPPLogger("test-service", "info")
+	caFile := writeTestCA(t, t.TempDir())
+
+	tests := []struct {
+		testName      string
+		config        ServerConfig
+		expectedError string
+	}{
+		{
+			testName: "CA file with an encrypted scheme",
+			config:   ServerConfig{NeoURL: "neo4j+s://neo4j.example.com:7687", NeoTLSCAFile: caFile},
+		},
+		{
+			testName:      "CA file with an unencrypted scheme",
+			config:        ServerConfig{NeoURL: "bolt://neo4j.example.com:7687", NeoTLSCAFile: caFile},
+			expectedError: "neo4j TLS CA file requires an encrypted URL scheme (neo4j+s or bolt+s), got bolt://neo4j.example.com:7687",
+		},
+		{
+			testName:      "Missing CA file with an encrypted scheme",
+			config:        ServerConfig{NeoURL: "bolt+s://neo4j.example.com:7687", NeoTLSCAFile: caFile + ".missing"},
+			expectedError: "reading neo4j TLS CA file",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			connector, err := newNeoConnector(test.config, log)
+			if test.expectedError != "" {
+				assert.ErrorContains(t, err, test.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			if assert.NotNil(t, connector) {
+				assert.Len(t, connector.configurers, 2)
+			}
+		})
+	}
+}
+
+func TestNeoConnector_Reconnect(t *testing.T) {
+	log := logger.NewUPPLogger("test-service", "panic")
+
+	t.Run("Retries until connected", func(t *testing.T) {
+		var attempts atomic.Int32
+		connector := newFailingConnector(log, &attempts, 3)
+
+		connected := make(chan *content.Driver, 1)
+		done := make(chan struct{})
+		go func() {
+			connector.reconnect(context.Background(), time.Millisecond, func(d *content.Driver) { connected <- d }, log)
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("reconnect did not return once connected")
+		}
+		assert.Equal(t, int32(3), attempts.Load())
+		if assert.Len(t, connected, 1) {
+			assert.Same(t, connector.driver, <-connected)
+		}
+	})
+
+	t.Run("Stops retrying when cancelled", func(t *testing.T) {
+		var attempts atomic.Int32
+		connector := newFailingConnector(log, &attempts, 0)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			connector.reconnect(ctx, time.Millisecond, func(*content.Driver) { t.Error("Unexpected connection") }, log)
+			close(done)
+		}()
+
+		assert.Eventually(t, func() bool { return attempts.Load() >= 2 }, 5*time.Second, time.Millisecond)
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("reconnect did not return once cancelled")
+		}
+		assert.Nil(t, connector.driver)
+	})
+}
+
+// newFailingConnector returns a connector failing to create a driver until the attempt succeeding, counting attempts.
+// It never succeeds when succeeding is zero.
+func newFailingConnector(log *logger.UPPLogger, attempts *atomic.Int32, succeeding int32) *neoConnector {
+	return &neoConnector{
+		url: "bolt://localhost:7687",
+		log: log,
+		newDriver: func(string, neo4j.AuthToken, string, ...func(*neo4j.Config)) (*content.Driver, error) {
+			if attempts.Add(1) != succeeding {
+				return nil, errors.New("connection refused")
+			}
+			return &content.Driver{}, nil
+		},
+	}
+}
+
+// writeTestCA writes a self-signed CA certificate in PEM format to dir and returns its path.
+func writeTestCA(t *testing.T, dir string) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test neo4j CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	file := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	return file
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/Financial-Times/go-logger/v2"
+	"github.com/Financial-Times/public-content-by-concept-api/v2/problem"
 	transactionidutils "github.com/Financial-Times/transactionid-utils-go"
 )
 
@@ -33,7 +34,7 @@ func IsAuthorizedPublication(n http.Handler, w http.ResponseWriter, req *http.Re
 			n.ServeHTTP(w, req)
 		} else {
 			logEntry.Infof("Request is forbidden due to missing or non-matching access policies: %s", r.Reasons)
-			http.Error(w, "Forbidden", http.StatusForbidden)
+			problem.Write(w, transID, problem.New(http.StatusForbidden, "Request is forbidden due to missing or non-matching access policies"))
 		}
 	}
 }
@@ -0,0 +1,73 @@
+// Package problem writes error responses as RFC 7807 problem details.
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const (
+	ContentType = "application/problem+json"
+	// DefaultType is used when the problem has no more meaning than its HTTP status code.
+	DefaultType = "about:blank"
+)
+
+// Problem types telling apart problems that share a status code, so that clients do not have to match on the detail.
+const (
+	// TypeConceptNotFound is the type of a 404 for a concept that does not exist.
+	TypeConceptNotFound = "urn:ft:public-content-by-concept-api:concept-not-found"
+	// TypeContentNotFound is the type of a 404 for a concept that exists but has no content to respond with.
+	TypeContentNotFound = "urn:ft:public-content-by-concept-api:content-not-found"
+)
+
+// Details is an RFC 7807 problem details object.
+type Details struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// Param is the request parameter that caused the problem, if any.
+	Param         string `json:"param,omitempty"`
+	TransactionID string `json:"transactionId,omitempty"`
+	// Message repeats Detail for clients relying on the error format used before problem details.
+	Message string `json:"message"`
+}
+
+// New creates the problem details for status. The title is the standard status text.
+func New(status int, detail string) Details {
+	return Details{
+		Type:    DefaultType,
+		Title:   http.StatusText(status),
+		Status:  status,
+		Detail:  detail,
+		Message: detail,
+	}
+}
+
+// WithParam returns a copy of d naming the request parameter that caused the problem.
+func (d Details) WithParam(param string) Details {
+	d.Param = param
+	return d
+}
+
+// WithType returns a copy of d with the given problem type.
+func (d Details) WithType(typ string) Details {
+	d.Type = typ
+	return d
+}
+
+// Write responds with the problem details for the request with the given transaction ID.
+func Write(w http.ResponseWriter, transID string, d Details) {
+	d.TransactionID = transID
+
+	body, err := json.Marshal(d)
+	if err != nil {
+		// cannot happen with the field types above
+		http.Error(w, d.Detail, d.Status)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(d.Status)
+	_, _ = w.Write(body)
+}
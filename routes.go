@@ -15,7 +15,6 @@ import (
 	"github.com/Financial-Times/public-content-by-concept-api/v2/policy"
 
 	"github.com/Financial-Times/api-endpoint"
-	cmneo4j "github.com/Financial-Times/cm-neo4j-driver"
 	"github.com/Financial-Times/go-logger/v2"
 	"github.com/Financial-Times/http-handlers-go/v2/httphandlers"
 	"github.com/Financial-Times/public-content-by-concept-api/v2/content"
@@ -23,16 +22,48 @@ import (
 )
 
 type ServerConfig struct {
-	Port          string
-	APIYMLPath    string
-	CacheTime     time.Duration
-	RecordMetrics bool
+	Port              string
+	APIYMLPath        string
+	CacheTime         time.Duration
+	RecordMetrics     bool
+	QueryDebugEnabled bool
+	ServerTiming      bool
+
+	RedirectToCanonical bool
+
+	FTURL                 string
+	TrustedForwardedHosts []string
+
+	MaxBatchSize     int
+	BatchConcurrency int
+
+	StrictValidation bool
+	MaxLimit         int
+	MaxPageDepth     int
+
+	MaxSubsidiaryDepth int
+
+	RelevanceWeights content.RelevanceWeights
 
 	AppSystemCode  string
 	AppName        string
 	AppDescription string
 
-	NeoURL string
+	NeoURL                   string
+	NeoUsername              string
+	NeoPassword              string
+	NeoPasswordFile          string
+	NeoDatabase              string
+	NeoTLSCAFile             string
+	NeoReconnectInterval     time.Duration
+	NeoMaxConnectionPoolSize int
+
+	SchemaCheckInterval time.Duration
+	RequireIndexes      bool
+	SlowQueryThreshold  time.Duration
+
+	TracingExporter     string
+	TracingOTLPEndpoint string
 }
 
 func StartServer(config ServerConfig, log *logger.UPPLogger, dbLog *logger.UPPLogger, apiURL string, opaClient *opa.OpenPolicyAgentClient) (func(), error) {
@@ -41,20 +72,72 @@ func StartServer(config ServerConfig, log *logger.UPPLogger, dbLog *logger.UPPLo
 		return nil, fmt.Errorf("failed to serve the API Endpoint for this service from file %s: %w", config.APIYMLPath, err)
 	}
 
-	neoDriver, err := cmneo4j.NewDefaultDriver(config.NeoURL, dbLog)
+	connector, err := newNeoConnector(config, dbLog)
+	if err != nil {
+		return nil, fmt.Errorf("configuring neo driver: %w", err)
+	}
+
+	shutdownTracing, err := setupTracing(config)
 	if err != nil {
-		return nil, fmt.Errorf("creating neo driver: %w", err)
+		return nil, fmt.Errorf("configuring tracing: %w", err)
 	}
 
-	cbcService, err := content.NewContentByConceptService(neoDriver, apiURL)
+	neoDriver, err := connector.connect()
+	if err != nil {
+		log.WithError(err).Error("Could not connect to Neo4j, starting in degraded mode")
+	}
+
+	var cbcService *content.ConceptService
+	promMetrics := NewMetrics(func() bool { return cbcService.Connected() })
+	if config.RecordMetrics {
+		promMetrics.RegisterHandlerMetrics(metrics.DefaultRegistry)
+	}
+
+	cbcService, err = content.NewContentByConceptService(neoDriver, apiURL,
+		content.WithSlowQueryLogging(log, config.SlowQueryThreshold),
+		content.WithQueryObserver(promMetrics),
+		content.WithPoolObserver(promMetrics),
+		content.WithFTURL(config.FTURL),
+		content.WithBatchConcurrency(config.BatchConcurrency),
+		content.WithRelevanceWeights(config.RelevanceWeights),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("creating content by concept service: %w", err)
 	}
 
+	if neoDriver != nil {
+		status, err := checkSchema(cbcService, log)
+		if config.RequireIndexes && err != nil {
+			_ = connector.close()
+			return nil, fmt.Errorf("checking required neo4j indexes: %w", err)
+		}
+		if config.RequireIndexes && !status.OK() {
+			_ = connector.close()
+			return nil, fmt.Errorf("required neo4j indexes are not available: %s", status)
+		}
+	}
+
+	bgCtx, cancelBackground := context.WithCancel(context.Background())
+	if neoDriver == nil {
+		// the service is already serving by then, so required indexes are enforced through GTG instead
+		go connector.reconnect(bgCtx, config.NeoReconnectInterval, func(driver *content.Driver) {
+			cbcService.SetDriver(driver)
+			_, _ = checkSchema(cbcService, log)
+		}, log)
+	}
+	go monitorSchema(bgCtx, cbcService, config.SchemaCheckInterval, log)
+
 	handler := Handler{
-		ContentService:     cbcService,
-		CacheControlHeader: strconv.FormatFloat(config.CacheTime.Seconds(), 'f', 0, 64),
-		Log:                log,
+		ContentService:      cbcService,
+		CacheControlHeader:  strconv.FormatFloat(config.CacheTime.Seconds(), 'f', 0, 64),
+		Log:                 log,
+		QueryDebugEnabled:   config.QueryDebugEnabled,
+		RedirectToCanonical: config.RedirectToCanonical,
+		StrictValidation:    config.StrictValidation,
+		MaxLimit:            config.MaxLimit,
+		MaxPageDepth:        config.MaxPageDepth,
+		MaxBatchSize:        config.MaxBatchSize,
+		MaxSubsidiaryDepth:  config.MaxSubsidiaryDepth,
 	}
 
 	hs := &HealthcheckService{
@@ -62,9 +145,20 @@ func StartServer(config ServerConfig, log *logger.UPPLogger, dbLog *logger.UPPLo
 		AppName:        config.AppName,
 		AppDescription: config.AppDescription,
 		ConnChecker:    cbcService.CheckConnection,
+		SchemaChecker:  cbcService.SchemaHealth,
+		RequireIndexes: config.RequireIndexes,
 	}
 
 	router := mux.NewRouter()
+	router.NotFoundHandler = http.HandlerFunc(routeNotFound)
+	router.MethodNotAllowedHandler = http.HandlerFunc(methodNotAllowed)
+	router.Use(promMetrics.InstrumentRoutes, traceRoutes)
+	if config.ServerTiming {
+		router.Use(recordServerTiming)
+	}
+	if len(config.TrustedForwardedHosts) > 0 {
+		router.Use(forwardedAPIURL(config.TrustedForwardedHosts))
+	}
 	log.Debug("Registering service handlers")
 	monitoredHandler := httphandlers.TransactionAwareRequestLoggingHandler(log, http.HandlerFunc(handler.GetContentByConcept))
 	if config.RecordMetrics {
@@ -76,24 +170,62 @@ func StartServer(config ServerConfig, log *logger.UPPLogger, dbLog *logger.UPPLo
 		monitoredImplicitHandler = httphandlers.HTTPMetricsHandler(metrics.DefaultRegistry, monitoredImplicitHandler)
 	}
 
+	monitoredBatchHandler := httphandlers.TransactionAwareRequestLoggingHandler(log, http.HandlerFunc(handler.GetContentByConcepts))
+	if config.RecordMetrics {
+		monitoredBatchHandler = httphandlers.HTTPMetricsHandler(metrics.DefaultRegistry, monitoredBatchHandler)
+	}
+
+	monitoredCountHandler := httphandlers.TransactionAwareRequestLoggingHandler(log, http.HandlerFunc(handler.GetContentCount))
+	if config.RecordMetrics {
+		monitoredCountHandler = httphandlers.HTTPMetricsHandler(metrics.DefaultRegistry, monitoredCountHandler)
+	}
+
+	monitoredHistogramHandler := httphandlers.TransactionAwareRequestLoggingHandler(log, http.HandlerFunc(handler.GetContentHistogram))
+	if config.RecordMetrics {
+		monitoredHistogramHandler = httphandlers.HTTPMetricsHandler(metrics.DefaultRegistry, monitoredHistogramHandler)
+	}
+
+	monitoredTrendingHandler := httphandlers.TransactionAwareRequestLoggingHandler(log, http.HandlerFunc(handler.GetTrendingConcepts))
+	if config.RecordMetrics {
+		monitoredTrendingHandler = httphandlers.HTTPMetricsHandler(metrics.DefaultRegistry, monitoredTrendingHandler)
+	}
+
+	monitoredCooccurrenceHandler := httphandlers.TransactionAwareRequestLoggingHandler(log, http.HandlerFunc(handler.GetCooccurringConcepts))
+	if config.RecordMetrics {
+		monitoredCooccurrenceHandler = httphandlers.HTTPMetricsHandler(metrics.DefaultRegistry, monitoredCooccurrenceHandler)
+	}
+
+	monitoredExpansionHandler := httphandlers.TransactionAwareRequestLoggingHandler(log, http.HandlerFunc(handler.GetConceptExpansion))
+	if config.RecordMetrics {
+		monitoredExpansionHandler = httphandlers.HTTPMetricsHandler(metrics.DefaultRegistry, monitoredExpansionHandler)
+	}
+
 	middlewareFunc := opa.CreateRequestMiddleware(opaClient, policy.PublicationPolicyKey, log, policy.IsAuthorizedPublication)
+	middlewareFunc = promMetrics.InstrumentPolicy(tracePolicy(timePolicy(middlewareFunc)))
 
-	//only use middleware for GetContentByConcept
+	//only use middleware for the endpoints that filter content by publication
 	authorizedRoutes := router.NewRoute().Subrouter()
-	authorizedRoutes.Use(middlewareFunc)
+	authorizedRoutes.Use(keepClientQuery, middlewareFunc)
 	authorizedRoutes.Handle("/content", monitoredHandler).Methods(http.MethodGet)
+	authorizedRoutes.Handle("/content/batch", monitoredBatchHandler).Methods(http.MethodPost)
+	authorizedRoutes.Handle("/content/count", monitoredCountHandler).Methods(http.MethodGet)
+	authorizedRoutes.Handle("/content/histogram", monitoredHistogramHandler).Methods(http.MethodGet)
+	authorizedRoutes.Handle("/concepts/trending", monitoredTrendingHandler).Methods(http.MethodGet)
+	authorizedRoutes.Handle("/concepts/{conceptUUID}/cooccurring", monitoredCooccurrenceHandler).Methods(http.MethodGet)
 
 	router.Handle("/content/{conceptUUID}/implicitly", monitoredImplicitHandler).Methods(http.MethodGet)
+	router.Handle("/concepts/{conceptUUID}/expansion", monitoredExpansionHandler).Methods(http.MethodGet)
 
 	log.Debug("Registering admin handlers")
 	router.HandleFunc("/__health", hs.HealthHandler()).Methods(http.MethodGet)
 	router.HandleFunc(st.GTGPath, st.NewGoodToGoHandler(hs.GTG)).Methods(http.MethodGet)
 	router.HandleFunc(st.BuildInfoPath, st.BuildInfoHandler).Methods(http.MethodGet)
 	router.HandleFunc(api.DefaultPath, apiEndpoint.ServeHTTP).Methods(http.MethodGet)
+	router.Handle(metricsPath, promMetrics.Handler()).Methods(http.MethodGet)
 
 	srv := http.Server{
 		Addr:    ":" + config.Port,
-		Handler: router,
+		Handler: traceRequests(router),
 	}
 
 	go func() {
@@ -105,6 +237,8 @@ func StartServer(config ServerConfig, log *logger.UPPLogger, dbLog *logger.UPPLo
 	}()
 
 	return func() {
+		cancelBackground()
+
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 		defer cancel()
 
@@ -112,8 +246,12 @@ func StartServer(config ServerConfig, log *logger.UPPLogger, dbLog *logger.UPPLo
 			log.WithError(err).Error("Server shutdown with unexpected error")
 		}
 
-		if err := neoDriver.Close(); err != nil {
+		if err := connector.close(); err != nil {
 			log.WithError(err).Error("Neo4j Driver failed to close")
 		}
+
+		if err := shutdownTracing(ctx); err != nil {
+			log.WithError(err).Error("Failed to flush traces")
+		}
 	}, nil
 }
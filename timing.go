@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const serverTimingHeader = "Server-Timing"
+
+// Phases reported in the Server-Timing header.
+const (
+	timingPolicy = "policy"
+	timingDB     = "db"
+	timingEncode = "encode"
+)
+
+type serverTimingKey struct{}
+
+// serverTiming collects how long the phases of a request took.
+type serverTiming struct {
+	mu     sync.Mutex
+	phases []timingPhase
+}
+
+type timingPhase struct {
+	name     string
+	duration time.Duration
+}
+
+func (t *serverTiming) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	metrics := make([]string, 0, len(t.phases))
+	for _, p := range t.phases {
+		metrics = append(metrics, fmt.Sprintf("%s;dur=%.1f", p.name, float64(p.duration.Microseconds())/1000))
+	}
+	return strings.Join(metrics, ", ")
+}
+
+// recordServerTiming is a mux middleware enabling the Server-Timing header for the request.
+func recordServerTiming(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), serverTimingKey{}, &serverTiming{})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// recordTiming adds the time since start to the Server-Timing header. A phase recorded more than once, e.g. the
+// queries of a request, is reported once with the total time. It does nothing unless the header is enabled.
+func recordTiming(ctx context.Context, phase string, start time.Time) {
+	t, ok := ctx.Value(serverTimingKey{}).(*serverTiming)
+	if !ok {
+		return
+	}
+
+	duration := time.Since(start)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i := range t.phases {
+		if t.phases[i].name == phase {
+			t.phases[i].duration += duration
+			return
+		}
+	}
+	t.phases = append(t.phases, timingPhase{name: phase, duration: duration})
+}
+
+// writeServerTiming sets the Server-Timing header from the phases recorded so far.
+// It has to be called before the response status is written.
+func writeServerTiming(ctx context.Context, w http.ResponseWriter) {
+	t, ok := ctx.Value(serverTimingKey{}).(*serverTiming)
+	if !ok {
+		return
+	}
+	if timing := t.String(); timing != "" {
+		w.Header().Set(serverTimingHeader, timing)
+	}
+}
+
+// timePolicy records how long the policy middleware takes to decide whether a request is allowed.
+func timePolicy(policyMiddleware mux.MiddlewareFunc) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			allowed := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				recordTiming(r.Context(), timingPolicy, start)
+				next.ServeHTTP(w, r)
+			})
+			policyMiddleware(allowed).ServeHTTP(w, r)
+		})
+	}
+}
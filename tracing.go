@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Supported values of the tracing-exporter option.
+const (
+	tracingExporterNone   = "none"
+	tracingExporterOTLP   = "otlp"
+	tracingExporterStdout = "stdout"
+)
+
+var tracer = otel.Tracer("github.com/Financial-Times/public-content-by-concept-api/v2")
+
+// setupTracing installs the global tracer provider and the W3C trace context propagator.
+// The returned func flushes the spans that have not been exported yet.
+func setupTracing(config ServerConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporter, err := newTraceExporter(config)
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceName(config.AppSystemCode),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// newTraceExporter returns the exporter selected by the tracing-exporter option, or nil when tracing is disabled.
+func newTraceExporter(config ServerConfig) (sdktrace.SpanExporter, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch config.TracingExporter {
+	case tracingExporterNone, "":
+		return nil, nil
+	case tracingExporterOTLP:
+		exporter, err = otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(config.TracingOTLPEndpoint))
+	case tracingExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("unsupported tracing exporter %q, expecting one of %s, %s or %s",
+			config.TracingExporter, tracingExporterNone, tracingExporterOTLP, tracingExporterStdout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating %s trace exporter: %w", config.TracingExporter, err)
+	}
+	return exporter, nil
+}
+
+// traceRequests starts a span for every incoming request, continuing the trace from the request headers if there is one.
+// Admin endpoints are not traced.
+func traceRequests(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "HTTP request",
+		otelhttp.WithFilter(func(r *http.Request) bool {
+			return !strings.HasPrefix(r.URL.Path, "/__") && r.URL.Path != metricsPath
+		}),
+	)
+}
+
+// traceRoutes is a mux middleware naming the request span after the matched route.
+func traceRoutes(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if current := mux.CurrentRoute(r); current != nil {
+			if tmpl, err := current.GetPathTemplate(); err == nil {
+				span := trace.SpanFromContext(r.Context())
+				span.SetName(r.Method + " " + tmpl)
+				span.SetAttributes(semconv.HTTPRoute(tmpl))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// tracePolicy records the policy decision in a span of its own. The span ends when the policy middleware
+// hands the request on or answers it itself.
+func tracePolicy(policyMiddleware mux.MiddlewareFunc) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			parent := trace.SpanFromContext(r.Context())
+			ctx, span := tracer.Start(r.Context(), "OPA decision")
+
+			decided := false
+			allowed := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				decided = true
+				span.SetAttributes(attribute.String("opa.decision", "allowed"))
+				span.End()
+				// the rest of the request belongs to the request span rather than the decision
+				next.ServeHTTP(w, r.WithContext(trace.ContextWithSpan(r.Context(), parent)))
+			})
+
+			policyMiddleware(allowed).ServeHTTP(w, r.WithContext(ctx))
+			if !decided {
+				span.SetAttributes(attribute.String("opa.decision", "rejected"))
+				span.End()
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+func TestNewTraceExporter(t *testing.T) {
+	tests := []struct {
+		testName         string
+		exporter         string
+		expectedExporter interface{}
+		expectedError    string
+	}{
+		{
+			testName: "Disabled by default",
+		},
+		{
+			testName: "Disabled",
+			exporter: tracingExporterNone,
+		},
+		{
+			testName:         "OTLP",
+			exporter:         tracingExporterOTLP,
+			expectedExporter: &otlptrace.Exporter{},
+		},
+		{
+			testName:         "Stdout",
+			exporter:         tracingExporterStdout,
+			expectedExporter: &stdouttrace.Exporter{},
+		},
+		{
+			testName:      "Unsupported",
+			exporter:      "zipkin",
+			expectedError: `unsupported tracing exporter "zipkin", expecting one of none, otlp or stdout`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			exporter, err := newTraceExporter(ServerConfig{TracingExporter: test.exporter, TracingOTLPEndpoint: "http://localhost:4318"})
+			if test.expectedError != "" {
+				assert.EqualError(t, err, test.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			if test.expectedExporter == nil {
+				assert.Nil(t, exporter)
+				return
+			}
+			assert.IsType(t, test.expectedExporter, exporter)
+		})
+	}
+}
+
+func TestTraceRequests_Spans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	// allows requests unless they are asked to be rejected, as the policy agent would
+	policyMiddleware := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("reject") != "" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	router := mux.NewRouter()
+	router.Use(traceRoutes)
+	authorizedRoutes := router.NewRoute().Subrouter()
+	authorizedRoutes.Use(tracePolicy(policyMiddleware))
+	authorizedRoutes.HandleFunc("/content/count", func(w http.ResponseWriter, r *http.Request) {}).Methods(http.MethodGet)
+	router.HandleFunc("/content/{conceptUUID}/implicitly", func(w http.ResponseWriter, r *http.Request) {}).Methods(http.MethodGet)
+	router.HandleFunc("/__gtg", func(w http.ResponseWriter, r *http.Request) {}).Methods(http.MethodGet)
+	handler := traceRequests(router)
+
+	tests := []struct {
+		testName         string
+		url              string
+		expectedSpans    []string
+		expectedRoute    string
+		expectedDecision string
+	}{
+		{
+			testName:      "Route template",
+			url:           "/content/" + testConceptID + "/implicitly",
+			expectedSpans: []string{"GET /content/{conceptUUID}/implicitly"},
+			expectedRoute: "/content/{conceptUUID}/implicitly",
+		},
+		{
+			testName:         "Policy allowed",
+			url:              "/content/count",
+			expectedSpans:    []string{"OPA decision", "GET /content/count"},
+			expectedRoute:    "/content/count",
+			expectedDecision: "allowed",
+		},
+		{
+			testName:         "Policy rejected",
+			url:              "/content/count?reject=true",
+			expectedSpans:    []string{"OPA decision", "GET /content/count"},
+			expectedRoute:    "/content/count",
+			expectedDecision: "rejected",
+		},
+		{
+			testName: "Admin endpoint",
+			url:      "/__gtg",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			before := len(recorder.Ended())
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, test.url, nil))
+
+			spans := recorder.Ended()[before:]
+			var names []string
+			for _, span := range spans {
+				names = append(names, span.Name())
+			}
+			assert.Equal(t, test.expectedSpans, names)
+			if len(spans) == 0 {
+				return
+			}
+
+			request := spans[len(spans)-1]
+			assert.Contains(t, request.Attributes(), semconv.HTTPRoute(test.expectedRoute))
+			if test.expectedDecision != "" {
+				decision := spans[0]
+				assert.Equal(t, request.SpanContext().SpanID(), decision.Parent().SpanID(), "The decision should be a child of the request span")
+				assert.Contains(t, decision.Attributes(), attribute.String("opa.decision", test.expectedDecision))
+			}
+		})
+	}
+}
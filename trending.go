@@ -0,0 +1,166 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Financial-Times/go-logger/v2"
+	"github.com/Financial-Times/public-content-by-concept-api/v2/content"
+	transactionidutils "github.com/Financial-Times/transactionid-utils-go"
+)
+
+const (
+	defaultTrendingWindow   = 24 * time.Hour
+	defaultTrendingBaseline = 7 * 24 * time.Hour
+	// maxTrendingSpan caps the window and the baseline together, as every piece of content in them is looked at.
+	maxTrendingSpan      = 90 * 24 * time.Hour
+	defaultTrendingLimit = 10
+	maxTrendingLimit     = 100
+	defaultMinCount      = 3
+)
+
+// trendingParams are the query parameters accepted by the trending concepts endpoint.
+var trendingParams = []string{"window", "baseline", "type", "publication", "minCount", "limit", "strict"}
+
+type trendingResponse struct {
+	Concepts []content.TrendingConcept `json:"concepts"`
+}
+
+// GetTrendingConcepts returns the concepts whose content grew the most in the recent window compared to the baseline window.
+func (h *Handler) GetTrendingConcepts(w http.ResponseWriter, r *http.Request) {
+	transID := transactionidutils.GetTransactionIDFromRequest(r)
+	ctx := transactionidutils.TransactionAwareContext(r.Context(), transID)
+
+	logEntry := h.Log.WithTransactionID(transID)
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set(transactionidutils.TransactionIDHeader, transID)
+
+	m, err := url.ParseQuery(r.URL.RawQuery)
+	if err != nil {
+		logEntry.WithError(err).Error("Could not parse request url")
+		writeProblem(ctx, w, http.StatusBadRequest, "", "Could not parse the request query")
+		return
+	}
+	logEntry.Debugf("Request url is %s", r.URL.RawQuery)
+
+	if _, err = h.strictRequested(m, trendingParams); err != nil {
+		writeRequestError(ctx, w, err)
+		return
+	}
+
+	params, err := extractTrendingParams(m, logEntry)
+	if err != nil {
+		writeRequestError(ctx, w, err)
+		return
+	}
+	params.Until = time.Now()
+
+	dbStart := time.Now()
+	concepts, err := h.ContentService.GetTrendingConcepts(ctx, params)
+	recordTiming(ctx, timingDB, dbStart)
+	if err != nil {
+		msg := "Backend error returning trending concepts"
+		logEntry.WithError(err).Error(msg)
+		writeProblem(ctx, w, http.StatusServiceUnavailable, "", msg)
+		return
+	}
+
+	encoded, err := encodeJSON(ctx, trendingResponse{Concepts: concepts})
+	if err != nil {
+		msg := "Error parsing returned trending concepts"
+		logEntry.WithError(err).Error(msg)
+		writeProblem(ctx, w, http.StatusInternalServerError, "", msg)
+		return
+	}
+
+	w.Header().Set("Cache-Control", h.CacheControlHeader)
+	writeServerTiming(ctx, w)
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(encoded); err != nil {
+		logEntry.WithError(err).Error("Error writing trending concepts")
+	}
+}
+
+// extractTrendingParams validates the parameters of the trending concepts endpoint. Unlike /content, values that cannot
+// be parsed are always rejected as there is no sensible fallback for a window.
+func extractTrendingParams(val url.Values, log *logger.LogEntry) (content.TrendingParams, error) {
+	window, err := durationParam(val, "window", defaultTrendingWindow)
+	if err != nil {
+		return content.TrendingParams{}, err
+	}
+	baseline, err := durationParam(val, "baseline", defaultTrendingBaseline)
+	if err != nil {
+		return content.TrendingParams{}, err
+	}
+	// each is checked on its own first, as durations this long would overflow when added together
+	if window > maxTrendingSpan {
+		return content.TrendingParams{}, newParamError("window", "window should not be longer than %s", maxTrendingSpan)
+	}
+	if baseline > maxTrendingSpan-window {
+		return content.TrendingParams{}, newParamError("baseline", "window and baseline together should not be longer than %s", maxTrendingSpan)
+	}
+
+	minCount, err := intParam(val, "minCount", defaultMinCount, 1, 0)
+	if err != nil {
+		return content.TrendingParams{}, err
+	}
+	limit, err := intParam(val, "limit", defaultTrendingLimit, 1, maxTrendingLimit)
+	if err != nil {
+		return content.TrendingParams{}, err
+	}
+
+	types, err := typeParam(val, "concept")
+	if err != nil {
+		return content.TrendingParams{}, err
+	}
+
+	publication, err := extractPublication(val, log)
+	if err != nil {
+		return content.TrendingParams{}, err
+	}
+
+	return content.TrendingParams{
+		Window:       window,
+		Baseline:     baseline,
+		ConceptTypes: types,
+		Publication:  publication,
+		MinCount:     minCount,
+		Limit:        limit,
+	}, nil
+}
+
+// durationParam returns the positive duration of the parameter, e.g. 24h, or def when it is not given.
+func durationParam(val url.Values, name string, def time.Duration) (time.Duration, error) {
+	param := val.Get(name)
+	if param == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(param)
+	if err != nil || d <= 0 {
+		return 0, newParamError(name, "provided value for %s, %s, could not be parsed. Expecting a positive duration, e.g. 24h", name, param)
+	}
+	return d, nil
+}
+
+// intParam returns the value of the parameter, or def when it is not given. The value must be at least lowest
+// and, unless highest is 0, at most highest.
+func intParam(val url.Values, name string, def, lowest, highest int) (int, error) {
+	param := val.Get(name)
+	if param == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(param)
+	if err != nil {
+		return 0, newParamError(name, "provided value for %s, %s, could not be parsed.", name, param)
+	}
+	if n < lowest {
+		return 0, newParamError(name, "provided value for %s should not be less than: %d", name, lowest)
+	}
+	if highest > 0 && n > highest {
+		return 0, newParamError(name, "provided value for %s should not be greater than: %d", name, highest)
+	}
+	return n, nil
+}